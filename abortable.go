@@ -20,6 +20,8 @@ func (ma *monitorAbortable) Abort() {
 		"monitored_gpid", ma.pid.String(),
 		"monitor_pid", ma.self.Id)
 
+	ma.self.removeMonitored(ma.pid)
+
 	go func() {
 		if ma.pid.MachineId != machineId {
 			//Monitor is not on this machine
@@ -31,7 +33,7 @@ func (ma *monitorAbortable) Abort() {
 
 			m, ok := getMachine(ma.pid.MachineId)
 
-			if ok && m.connected {
+			if ok && m.isConnected() {
 				//send demonitor request to demonitor channel on the machine connection
 				m.demonitorChan <- remoteMonitorTuple{From: ma.self, To: ma.pid}
 				return
@@ -51,6 +53,11 @@ func (ma *monitorAbortable) Abort() {
 			}
 		}()
 
+		if p := dispatchedPid(ma.pid); p != nil {
+			p.pushSignal(demonitorSignal{monitor: ma.self})
+			return
+		}
+
 		if ma.pid.demonitorChan == nil {
 			logger.Warn("pid to demonitor is already down",
 				"monitored_gpid", ma.pid.String(),
@@ -82,14 +89,25 @@ func (ma *machineConnectionMonitorAbortable) Abort() {
 			}
 		}()
 
-		if !ma.machine.connected {
+		if !ma.machine.isConnected() {
 			logger.Warn("machine connection to demonitor is already down",
 				"machine_id", ma.machine.MachineId,
 				"monitor_pid", ma.monitor.Id)
 			return
 		}
 
-		ma.machine.monitorQuitChannels[ma.monitor.String()] <- true
+		name := ma.monitor.String()
+
+		monitorQuitChannel, ok := ma.machine.monitorQuitChannels[name]
+		if !ok {
+			return
+		}
+
+		monitorQuitChannel <- true
+
+		delete(ma.machine.scheduled, name)
+		delete(ma.machine.monitorQuitChannels, name)
+		delete(ma.machine.monitors, name)
 	}()
 }
 
@@ -106,6 +124,15 @@ func (sa *sendAfterAbortable) Abort() {
 	sa.quitChan <- true
 }
 
+type timerAbortable struct {
+	pid *Pid
+	key string
+}
+
+func (ta *timerAbortable) Abort() {
+	ta.pid.cancelTimer(ta.key)
+}
+
 type noopAbortable struct {
 }
 
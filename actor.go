@@ -1,10 +1,13 @@
 package quacktors
 
 import (
+	"fmt"
 	"github.com/Azer0s/quacktors/mailbox"
 	"github.com/Azer0s/quacktors/metrics"
 	"github.com/opentracing/opentracing-go"
+	"runtime/debug"
 	"sync"
+	"time"
 )
 
 //The Actor interface defines the methods a struct has to implement
@@ -57,64 +60,98 @@ func (s *StatelessActor) Run(ctx *Context, message Message) {
 	s.ReceiveFunction(ctx, message)
 }
 
-func doSend(to *Pid, message Message, spanContext opentracing.SpanContext) {
-	returnChan := make(chan bool)
-
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				//This happens if we write to the messageChan while the actor or remote connection is being closed
-				metrics.RecordUnhandled(to.Id)
-			}
+func doSend(to *Pid, message Message, spanContext opentracing.SpanContext) error {
+	return deliver(to, localMessage{
+		message:     message,
+		spanContext: spanContext,
+	})
+}
 
-			//As soon as we have put the message into the buffered messageChan, return
-			//This is to preserve message ordering
-			returnChan <- true
-		}()
+//deliver puts a message into the mailbox of a local actor (or hands it
+//to the connection of a remote machine) right away. As soon as deliver
+//returns, the message is in the mailbox, which preserves message ordering.
+func deliver(to *Pid, m localMessage) error {
+	if to.MachineId != machineId {
+		//Pid is not on this machine
+		deliverRemote(to, m)
+		return nil
+	}
 
-		if to.MachineId != machineId {
-			//Pid is not on this machine
+	return deliverLocal(to, m)
+}
 
-			m, ok := getMachine(to.MachineId)
+func deliverLocal(to *Pid, m localMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			//This happens if we write to the mailbox while the actor is being closed
+			metrics.RecordUnhandled(to.Id)
+			err = nil
+		}
+	}()
 
-			if ok && m.connected {
-				m.messageChan <- remoteMessageTuple{
-					To:          to,
-					Message:     message,
-					SpanContext: spanContext,
-				}
+	mb := to.mailbox
 
-				metrics.RecordSendRemote(to.Id)
-			}
+	if mb == nil {
+		//Maybe the current pid instance is just empty but the pid actually does exist on our local machine
+		//This can happen when you send the pid to a remote machine and receive it back
+		p, ok := getByPidId(to.Id)
 
-			return
+		if !ok {
+			//the pid might be the reply target of an Ask
+			resolveFuture(to.Id, m.message)
+			return nil
 		}
 
-		//If the actor has already quit, do nothing
-		if to.messageChan == nil {
-			//Maybe the current pid instance is just empty but the pid actually does exist on our local machine
-			//This can happen when you send the pid to a remote machine and receive it back
-			p, ok := getByPidId(to.Id)
+		mb = p.mailbox
+	}
 
-			if ok {
-				p.messageChan <- localMessage{
-					message:     message,
-					spanContext: spanContext,
-				}
-				metrics.RecordSendLocal(p.Id)
-			}
+	//pushing to the mailbox of an actor that went down panics
+	err = mb.Push(m)
 
-			return
-		}
+	if err == nil {
+		metrics.RecordSendLocal(to.Id)
+	}
+
+	return err
+}
 
-		to.messageChan <- localMessage{
-			message:     message,
-			spanContext: spanContext,
+func deliverRemote(to *Pid, m localMessage) {
+	defer func() {
+		if r := recover(); r != nil {
+			//This happens if we write to the remote connection while it is being closed
+			metrics.RecordUnhandled(to.Id)
 		}
-		metrics.RecordSendLocal(to.Id)
 	}()
 
-	<-returnChan
+	machine, ok := getMachine(to.MachineId)
+
+	if ok && machine.isConnected() {
+		machine.messageChan <- remoteMessageTuple{
+			To:          to,
+			Message:     m.message,
+			Sender:      m.sender,
+			ReplyTo:     m.replyTo,
+			SpanContext: m.spanContext,
+		}
+
+		metrics.RecordSendRemote(to.Id)
+	}
+}
+
+func isPriorityMessage(elem interface{}) bool {
+	m, ok := elem.(localMessage)
+	if !ok {
+		return false
+	}
+
+	switch msg := m.message.(type) {
+	case PoisonPill, DownMessage, ExitMessage, DisconnectMessage, KillMessage:
+		return true
+	case PriorityMessage:
+		return msg.Priority()
+	}
+
+	return false
 }
 
 func recordDroppedMessages(pidId string, mb *mailbox.Mailbox) {
@@ -125,22 +162,79 @@ func recordDroppedMessages(pidId string, mb *mailbox.Mailbox) {
 	}
 }
 
-func startActor(actor Actor) *Pid {
-	quitChan := make(chan bool)      //channel to quit
-	mb := mailbox.New()              //message mailbox
-	monitorChan := make(chan *Pid)   //channel to notify the actor of who wants to monitor it
-	demonitorChan := make(chan *Pid) //channel to notify the actor of who wants to unmonitor it
+func handleMonitorRequest(pid *Pid, monitor *Pid) {
+	logger.Info("actor received monitor request",
+		"pid", pid.Id,
+		"monitor_gpid", monitor.String())
+	pid.setupMonitor(monitor)
+}
+
+func handleDemonitorRequest(pid *Pid, monitor *Pid) {
+	logger.Info("actor received demonitor request",
+		"pid", pid.Id,
+		"monitor_gpid", monitor.String())
+	pid.removeMonitor(monitor)
+}
+
+func startActor(actor Actor, options ...SpawnOption) *Pid {
+	opts := &spawnOptions{
+		mailboxOptions: make([]mailbox.Option, 0),
+	}
+
+	for _, option := range options {
+		option(opts)
+	}
+
+	var pid *Pid
+
+	//dispatched is only set if the actor runs on a Dispatcher
+	var dispatched *dispatchedActor
+
+	if opts.dispatcher != nil {
+		dispatched = opts.dispatcher.attach()
+		opts.mailboxOptions = append(opts.mailboxOptions, mailbox.Scheduled(dispatched.schedule))
+	}
+
+	mb := mailbox.New(append(opts.mailboxOptions, mailbox.OnDrop(func(amount int) {
+		//a bounded mailbox dropped or rejected a message
+		metrics.RecordDrop(pid.Id, amount)
+	}))...) //message mailbox
+
+	//actors that run on a Dispatcher receive system requests through their mailbox
+	var quitChan chan bool
+	var monitorChan, demonitorChan, linkChan, unlinkChan chan *Pid
+	var exitChan chan ExitMessage
+
+	if dispatched == nil {
+		quitChan = make(chan bool)        //channel to quit
+		monitorChan = make(chan *Pid)     //channel to notify the actor of who wants to monitor it
+		demonitorChan = make(chan *Pid)   //channel to notify the actor of who wants to unmonitor it
+		linkChan = make(chan *Pid)        //channel to notify the actor of who wants to link to it
+		unlinkChan = make(chan *Pid)      //channel to notify the actor of who wants to unlink from it
+		exitChan = make(chan ExitMessage) //channel to notify the actor that a linked actor went down
+	}
 
 	scheduled := make(map[string]chan bool)
 	monitorQuitChannels := make(map[string]chan bool)
 
-	pid := createPid(quitChan, mb.In(), monitorChan, demonitorChan, scheduled, monitorQuitChannels)
+	pid = createPid(quitChan, mb, monitorChan, demonitorChan, linkChan, unlinkChan, exitChan, scheduled, monitorQuitChannels, dispatched != nil)
+	pid.parent = opts.parent
 	ctx := &Context{
-		self:      pid,
-		Logger:    contextLogger{pid: pid.Id},
-		sendLock:  &sync.Mutex{},
-		deferred:  make([]func(), 0),
-		traceFork: opentracing.FollowsFrom,
+		self:          pid,
+		Logger:        contextLogger{pid: pid.Id},
+		sendLock:      &sync.Mutex{},
+		deferred:      make([]func(), 0),
+		traceFork:     opentracing.FollowsFrom,
+		mailbox:       mb,
+		quitChan:      quitChan,
+		monitorChan:   monitorChan,
+		demonitorChan: demonitorChan,
+		linkChan:      linkChan,
+		unlinkChan:    unlinkChan,
+		exitChan:      exitChan,
+		stash:         make([]interface{}, 0),
+		stashCapacity: defaultStashCapacity,
+		children:      make([]*Pid, 0),
 	}
 
 	//Initialize the actor
@@ -154,43 +248,235 @@ func startActor(actor Actor) *Pid {
 
 	messageChan := mb.Out()
 
-	go func() {
-		defer func() {
-			//We don't want to forward a panic
-			if r := recover(); r != nil {
-				if _, ok := r.(quitAction); ok {
-					logger.Info("actor quit",
-						"pid", pid.Id)
-				} else {
-					//if we did pick up a panic, log it
-					logger.Warn("actor quit due to panic",
-						"pid", pid.Id,
-						"panic", r)
-				}
+	//lock-free mailboxes don't have an output channel, the actor parks on them instead
+	getWaitChan := func() <-chan bool {
+		if messageChan != nil {
+			return nil
+		}
+
+		return mb.Wait()
+	}
+
+	//idleTimer fires if the actor hasn't received a message within the receive timeout
+	var idleTimer *time.Timer
+	//idleGeneration tells stale receive timeouts of dispatched actors apart
+	var idleGeneration uint64
+
+	resetIdleTimer := func() {
+		if dispatched != nil {
+			if idleTimer != nil {
+				idleTimer.Stop()
+			}
+
+			idleGeneration++
+
+			if ctx.receiveTimeout <= 0 {
+				return
+			}
+
+			generation := idleGeneration
+			idleTimer = time.AfterFunc(ctx.receiveTimeout, func() {
+				pid.pushSignal(idleSignal{generation: generation})
+			})
+
+			return
+		}
+
+		if idleTimer != nil && !idleTimer.Stop() {
+			//drain the channel if the timer has already fired
+			select {
+			case <-idleTimer.C:
+			default:
+			}
+		}
+
+		if ctx.receiveTimeout <= 0 {
+			return
+		}
+
+		if idleTimer == nil {
+			idleTimer = time.NewTimer(ctx.receiveTimeout)
+			return
+		}
+
+		idleTimer.Reset(ctx.receiveTimeout)
+	}
+
+	getIdleChan := func() <-chan time.Time {
+		if idleTimer == nil || ctx.receiveTimeout <= 0 {
+			return nil
+		}
+
+		return idleTimer.C
+	}
+
+	resetIdleTimer()
+
+	run := func(m localMessage) {
+		ctx.span = nil
+		ctx.current = &m
+		ctx.stashed = false
+		pid.current.Store(m.message.Type())
+
+		if d, ok := m.message.(DownMessage); ok {
+			//the monitor is gone as soon as the DownMessage arrives
+			pid.removeMonitored(d.Who)
+		}
+
+		func() {
+			if m.spanContext != nil && ctx.traceName != "" {
+				span := opentracing.GlobalTracer().StartSpan(ctx.traceName,
+					ctx.traceFork(m.spanContext))
+				span.SetTag("pid", pid.Id)
+				span.SetTag("machine_id", pid.MachineId)
+				ctx.span = span
+
+				defer span.Finish()
 			}
 
-			//We don't really care how the actor died, we just wanna know that it did
-			metrics.RecordDie(pid.Id)
-			recordDroppedMessages(pid.Id, mb)
-
-			if len(ctx.deferred) != 0 {
-				ctx.Logger.Debug("executing deferred actor actions")
-
-				for _, action := range ctx.deferred {
-					func() {
-						defer func() {
-							if r := recover(); r != nil {
-								//action failed but we want to ignore that
-							}
-						}()
-						action()
+			ctx.receive(actor, m.message)
+		}()
+
+		//Clean after run so the span won't be sent in any defers if the actor goes down right after
+		ctx.span = nil
+		ctx.current = nil
+		pid.current.Store("")
+
+		//every message restarts the receive timeout
+		resetIdleTimer()
+	}
+
+	//receive runs a message from the mailbox and returns false if it was a PoisonPill
+	receive := func(m localMessage) bool {
+		if m.stale() {
+			//the timer of the message was canceled (or replaced) after it fired
+			return true
+		}
+
+		metrics.RecordReceive(pid.Id)
+		pid.received.Inc()
+
+		if _, ok := m.message.(PoisonPill); ok && !ctx.passthroughPoisonPill {
+			logger.Info("actor received poison pill",
+				"pid", pid.Id)
+			return false
+		}
+
+		run(m)
+
+		return true
+	}
+
+	//stop is called exactly once as soon as the actor loop is left
+	stop := func(reason ExitReason) {
+		//Take down the whole subtree before anything else
+		ctx.stopChildren()
+
+		//We don't really care how the actor died, we just wanna know that it did
+		metrics.RecordDie(pid.Id)
+		recordDroppedMessages(pid.Id, mb)
+
+		if len(ctx.stash) != 0 {
+			//messages that were never unstashed are dropped as well
+			metrics.RecordDrop(pid.Id, len(ctx.stash))
+		}
+
+		if len(ctx.deferred) != 0 {
+			ctx.Logger.Debug("executing deferred actor actions")
+
+			for _, action := range ctx.deferred {
+				func() {
+					defer func() {
+						if r := recover(); r != nil {
+							//action failed but we want to ignore that
+						}
 					}()
+					action()
+				}()
+			}
+
+			ctx.deferred = make([]func(), 0)
+		}
+
+		if idleTimer != nil {
+			idleTimer.Stop()
+		}
+
+		pid.cleanup(reason)
+	}
+
+	if dispatched != nil {
+		dispatched.pid = pid
+		dispatched.process = func(elem interface{}) (alive bool) {
+			//reason is set whenever the actor goes down
+			var reason ExitReason
+			alive = true
+
+			defer func() {
+				if r := recover(); r != nil {
+					reason = recoveredExitReason(pid, r)
+					alive = false
 				}
 
-				ctx.deferred = make([]func(), 0)
+				if !alive {
+					//the children of the actor might run on the same dispatcher
+					//so we can't wait for them on a worker
+					go stop(reason)
+				}
+			}()
+
+			switch s := elem.(type) {
+			case quitSignal:
+				logger.Info("actor received quit event",
+					"pid", pid.Id)
+				reason = ExitReason{Kind: KILLED_EXIT}
+				return false
+			case localMessage:
+				if !receive(s) {
+					//Quit actor on PoisonPill message
+					reason = ExitReason{Kind: POISON_PILL_EXIT}
+					return false
+				}
+			case monitorSignal:
+				handleMonitorRequest(pid, s.monitor)
+			case demonitorSignal:
+				handleDemonitorRequest(pid, s.monitor)
+			case linkSignal:
+				handleLinkRequest(pid, s.from)
+			case unlinkSignal:
+				handleUnlinkRequest(pid, s.from)
+			case ExitMessage:
+				if ctx.handleExitSignal(s) {
+					run(localMessage{message: s})
+				}
+			case idleSignal:
+				if s.generation == idleGeneration && ctx.receiveTimeout > 0 {
+					run(localMessage{message: ReceiveTimeoutMessage{}})
+				}
+			}
+
+			return true
+		}
+
+		//messages that were sent during Init
+		if mb.Pending() {
+			dispatched.schedule()
+		}
+
+		return pid
+	}
+
+	go func() {
+		//reason is set whenever the actor loop is left
+		var reason ExitReason
+
+		defer func() {
+			//We don't want to forward a panic
+			if r := recover(); r != nil {
+				reason = recoveredExitReason(pid, r)
 			}
 
-			pid.cleanup()
+			stop(reason)
 		}()
 
 		for {
@@ -198,50 +484,59 @@ func startActor(actor Actor) *Pid {
 			case <-quitChan:
 				logger.Info("actor received quit event",
 					"pid", pid.Id)
+				reason = ExitReason{Kind: KILLED_EXIT}
 				return
 			case mi := <-messageChan:
-				metrics.RecordReceive(pid.Id)
-
-				m := mi.(localMessage)
-
-				if _, ok := m.message.(PoisonPill); ok && !ctx.passthroughPoisonPill {
-					logger.Info("actor received poison pill",
-						"pid", pid.Id)
+				if !receive(mi.(localMessage)) {
 					//Quit actor on PoisonPill message
+					reason = ExitReason{Kind: POISON_PILL_EXIT}
 					return
 				}
+			case <-getWaitChan():
+				mi, ok := mb.Pop()
 
-				ctx.span = nil
-
-				func() {
-					if m.spanContext != nil && ctx.traceName != "" {
-						span := opentracing.GlobalTracer().StartSpan(ctx.traceName,
-							ctx.traceFork(m.spanContext))
-						span.SetTag("pid", pid.Id)
-						span.SetTag("machine_id", pid.MachineId)
-						ctx.span = span
-
-						defer span.Finish()
-					}
-
-					actor.Run(ctx, m.message)
-				}()
-
-				//Clean after run so the span won't be sent in any defers if the actor goes down right after
-				ctx.span = nil
+				if ok && !receive(mi.(localMessage)) {
+					reason = ExitReason{Kind: POISON_PILL_EXIT}
+					return
+				}
 			case monitor := <-monitorChan:
-				logger.Info("actor received monitor request",
-					"pid", pid.Id,
-					"monitor_gpid", monitor.String())
-				pid.setupMonitor(monitor)
+				handleMonitorRequest(pid, monitor)
 			case monitor := <-demonitorChan:
-				logger.Info("actor received demonitor request",
-					"pid", pid.Id,
-					"monitor_gpid", monitor.String())
-				pid.removeMonitor(monitor)
+				handleDemonitorRequest(pid, monitor)
+			case from := <-linkChan:
+				handleLinkRequest(pid, from)
+			case from := <-unlinkChan:
+				handleUnlinkRequest(pid, from)
+			case e := <-exitChan:
+				if ctx.handleExitSignal(e) {
+					run(localMessage{message: e})
+				}
+			case <-getIdleChan():
+				run(localMessage{message: ReceiveTimeoutMessage{}})
 			}
 		}
 	}()
 
 	return pid
 }
+
+//recoveredExitReason turns whatever was recovered from the actor
+//loop (a quitAction or a panic) into an ExitReason
+func recoveredExitReason(pid *Pid, r interface{}) ExitReason {
+	if q, ok := r.(quitAction); ok {
+		logger.Info("actor quit",
+			"pid", pid.Id)
+		return q.reason
+	}
+
+	//if we did pick up a panic, log it
+	logger.Warn("actor quit due to panic",
+		"pid", pid.Id,
+		"panic", r)
+
+	return ExitReason{
+		Kind:  PANIC_EXIT,
+		Value: fmt.Sprint(r),
+		Stack: string(debug.Stack()),
+	}
+}
@@ -2,6 +2,7 @@ package quacktors
 
 import (
 	"errors"
+	"github.com/Azer0s/quacktors/mailbox"
 	"github.com/Azer0s/quacktors/typeregister"
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/atomic"
@@ -92,23 +93,35 @@ func VectorContext(name string, span opentracing.Span) Context {
 //Spawn spawns an Actor from an anonymous receive function and
 //returns the *Pid of the Actor.
 func Spawn(action func(ctx *Context, message Message)) *Pid {
+	return SpawnWithOptions(action)
+}
+
+//SpawnWithOptions is the same as Spawn but with SpawnOptions
+//(e.g. a bounded mailbox, see WithBoundedMailbox).
+func SpawnWithOptions(action func(ctx *Context, message Message), options ...SpawnOption) *Pid {
 	callInitIfNotCalled()
 
 	return startActor(&StatelessActor{
 		InitFunction:    func(ctx *Context) {},
 		ReceiveFunction: action,
-	})
+	}, options...)
 }
 
 //SpawnWithInit spawns an Actor from an anonymous receive function
 //and an anonymous init function and returns the *Pid of the Actor.
 func SpawnWithInit(init func(ctx *Context), action func(ctx *Context, message Message)) *Pid {
+	return SpawnWithInitWithOptions(init, action)
+}
+
+//SpawnWithInitWithOptions is the same as SpawnWithInit but with
+//SpawnOptions (e.g. a bounded mailbox, see WithBoundedMailbox).
+func SpawnWithInitWithOptions(init func(ctx *Context), action func(ctx *Context, message Message), options ...SpawnOption) *Pid {
 	callInitIfNotCalled()
 
 	return startActor(&StatelessActor{
 		InitFunction:    init,
 		ReceiveFunction: action,
-	})
+	}, options...)
 }
 
 //SpawnStateful spawns an Actor.
@@ -118,6 +131,73 @@ func SpawnStateful(actor Actor) *Pid {
 	return startActor(actor)
 }
 
+//A SpawnOption configures how an Actor is spawned (see
+//SpawnWithOptions, SpawnWithInitWithOptions and
+//SpawnStatefulWithOptions).
+type SpawnOption func(options *spawnOptions)
+
+type spawnOptions struct {
+	mailboxOptions []mailbox.Option
+	parent         *Pid
+	dispatcher     *Dispatcher
+}
+
+func withParent(parent *Pid) SpawnOption {
+	return func(options *spawnOptions) {
+		options.parent = parent
+	}
+}
+
+//WithBoundedMailbox spawns an Actor with a mailbox that holds
+//at most capacity messages. The policy defines what happens
+//when the mailbox is full (i.e. drop the newest or oldest
+//message, block the sender or reject the message). Every
+//dropped or rejected message is recorded as dropped.
+func WithBoundedMailbox(capacity int, policy mailbox.OverflowPolicy) SpawnOption {
+	return func(options *spawnOptions) {
+		options.mailboxOptions = append(options.mailboxOptions, mailbox.Bounded(capacity, policy))
+	}
+}
+
+//WithPriorityMailbox spawns an Actor with a mailbox that has
+//a priority lane. System messages (PoisonPill, DownMessage,
+//DisconnectMessage and KillMessage) as well as every
+//PriorityMessage that returns true on Priority skip ahead of
+//all normal messages. Note that this also means that a
+//PoisonPill no longer waits for already queued messages.
+func WithPriorityMailbox() SpawnOption {
+	return func(options *spawnOptions) {
+		options.mailboxOptions = append(options.mailboxOptions, mailbox.Priority(isPriorityMessage))
+	}
+}
+
+//WithLockFreeMailbox spawns an Actor with a mailbox that is
+//backed by lock-free queues instead of a goroutine and two
+//channels (see mailbox.LockFree). It can be combined with all
+//other mailbox options.
+func WithLockFreeMailbox() SpawnOption {
+	return func(options *spawnOptions) {
+		options.mailboxOptions = append(options.mailboxOptions, mailbox.LockFree())
+	}
+}
+
+//WithDispatcher spawns an Actor that runs on a Dispatcher
+//(see NewDispatcher and NewPinnedDispatcher) instead of a
+//goroutine of its own.
+func WithDispatcher(dispatcher *Dispatcher) SpawnOption {
+	return func(options *spawnOptions) {
+		options.dispatcher = dispatcher
+	}
+}
+
+//SpawnStatefulWithOptions spawns an Actor with the provided
+//SpawnOptions and returns the *Pid of the Actor.
+func SpawnStatefulWithOptions(actor Actor, options ...SpawnOption) *Pid {
+	callInitIfNotCalled()
+
+	return startActor(actor, options...)
+}
+
 //NewSystem creates a new system server, connects to
 //qpmd, starts the qpmd heartbeat for the new system and
 //returns a *System and an error (nil if everything
@@ -132,8 +212,7 @@ func NewSystem(name string) (*System, error) {
 		name:              name,
 		handlers:          make(map[string]*Pid),
 		handlersMu:        &sync.RWMutex{},
-		quitChan:          make(chan bool),
-		heartbeatQuitChan: make(chan bool),
+		heartbeatQuitChan: make(chan bool, 1),
 	}
 	p, err := s.startServer()
 
@@ -154,6 +233,7 @@ func NewSystem(name string) (*System, error) {
 	}
 
 	qpmdHeartbeat(conn, s)
+	registerSystem(s)
 
 	return s, nil
 }
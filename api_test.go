@@ -2,7 +2,12 @@ package quacktors
 
 import (
 	"fmt"
+	"github.com/Azer0s/qpmd"
+	"github.com/Azer0s/quacktors/mailbox"
 	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"net"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -79,6 +84,7 @@ func TestMonitorWithKill(t *testing.T) {
 		switch m := message.(type) {
 		case DownMessage:
 			assert.Equal(t, p.String(), m.Who.String())
+			assert.Equal(t, KILLED_EXIT, m.Reason.Kind)
 			fmt.Println("Actor went down!")
 			ctx.Quit()
 		}
@@ -107,6 +113,7 @@ func TestMonitorWithPoisonPill(t *testing.T) {
 		switch m := message.(type) {
 		case DownMessage:
 			assert.Equal(t, p.String(), m.Who.String())
+			assert.Equal(t, POISON_PILL_EXIT, m.Reason.Kind)
 			fmt.Println("Actor went down!")
 			ctx.Quit()
 		}
@@ -155,6 +162,304 @@ func TestMonitorAbortable_Abort(t *testing.T) {
 	Run()
 }
 
+func TestMonitorExitReason(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := Spawn(func(ctx *Context, message Message) {
+		panic("oh no")
+	})
+
+	reasons := make(chan ExitReason, 1)
+
+	SpawnWithInit(func(ctx *Context) {
+		ctx.Monitor(p)
+	}, func(ctx *Context, message Message) {
+		switch m := message.(type) {
+		case DownMessage:
+			reasons <- m.Reason
+			ctx.Quit()
+		}
+	})
+
+	rootCtx.Send(p, EmptyMessage{})
+
+	reason := <-reasons
+	assert.Equal(t, PANIC_EXIT, reason.Kind)
+	assert.Equal(t, "oh no", reason.Value)
+	assert.NotEmpty(t, reason.Stack)
+	assert.True(t, reason.Abnormal())
+
+	Run()
+}
+
+func TestLinkAbnormalExit(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := Spawn(func(ctx *Context, message Message) {
+		panic("oh no")
+	})
+
+	reasons := make(chan ExitReason, 1)
+
+	linked := SpawnWithInit(func(ctx *Context) {
+		ctx.Link(p)
+	}, func(ctx *Context, message Message) {
+	})
+
+	SpawnWithInit(func(ctx *Context) {
+		ctx.Monitor(linked)
+	}, func(ctx *Context, message Message) {
+		switch m := message.(type) {
+		case DownMessage:
+			reasons <- m.Reason
+			ctx.Quit()
+		}
+	})
+
+	//give the link request some time to get through
+	<-time.After(50 * time.Millisecond)
+	rootCtx.Send(p, EmptyMessage{})
+
+	reason := <-reasons
+	assert.Equal(t, PANIC_EXIT, reason.Kind)
+	assert.Equal(t, "oh no", reason.Value)
+
+	Run()
+}
+
+func TestLinkNormalExit(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := Spawn(func(ctx *Context, message Message) {
+		ctx.Quit()
+	})
+
+	received := make(chan Message, 1)
+
+	linked := SpawnWithInit(func(ctx *Context) {
+		ctx.Link(p)
+	}, func(ctx *Context, message Message) {
+		received <- message
+		ctx.Quit()
+	})
+
+	<-time.After(50 * time.Millisecond)
+	rootCtx.Send(p, EmptyMessage{})
+
+	//the linked actor has to survive a normal exit
+	<-time.After(50 * time.Millisecond)
+	rootCtx.Send(linked, GenericMessage{Value: "alive"})
+
+	assert.Equal(t, GenericMessage{Value: "alive"}, <-received)
+
+	Run()
+}
+
+func TestLinkTrapExits(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := Spawn(func(ctx *Context, message Message) {
+		panic("oh no")
+	})
+
+	exits := make(chan ExitMessage, 1)
+
+	SpawnWithInit(func(ctx *Context) {
+		ctx.TrapExits(true)
+		ctx.Link(p)
+	}, func(ctx *Context, message Message) {
+		switch m := message.(type) {
+		case ExitMessage:
+			exits <- m
+			ctx.Quit()
+		}
+	})
+
+	<-time.After(50 * time.Millisecond)
+	rootCtx.Send(p, EmptyMessage{})
+
+	e := <-exits
+	assert.True(t, e.Who.Is(p))
+	assert.Equal(t, PANIC_EXIT, e.Reason.Kind)
+
+	Run()
+}
+
+func TestLinkDeadPid(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := Spawn(func(ctx *Context, message Message) {
+		ctx.Quit()
+	})
+
+	rootCtx.Send(p, EmptyMessage{})
+	<-time.After(50 * time.Millisecond)
+
+	exits := make(chan ExitMessage, 1)
+
+	SpawnWithInit(func(ctx *Context) {
+		ctx.TrapExits(true)
+		ctx.Link(p)
+	}, func(ctx *Context, message Message) {
+		switch m := message.(type) {
+		case ExitMessage:
+			exits <- m
+			ctx.Quit()
+		}
+	})
+
+	e := <-exits
+	assert.Equal(t, NO_PROC_EXIT, e.Reason.Kind)
+
+	Run()
+}
+
+func TestUnlink(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := Spawn(func(ctx *Context, message Message) {
+		panic("oh no")
+	})
+
+	received := make(chan Message, 1)
+
+	linked := SpawnWithInit(func(ctx *Context) {
+		ctx.Link(p)
+		ctx.Unlink(p)
+	}, func(ctx *Context, message Message) {
+		received <- message
+		ctx.Quit()
+	})
+
+	<-time.After(50 * time.Millisecond)
+	rootCtx.Send(p, EmptyMessage{})
+
+	<-time.After(50 * time.Millisecond)
+	rootCtx.Send(linked, GenericMessage{Value: "alive"})
+
+	assert.Equal(t, GenericMessage{Value: "alive"}, <-received)
+
+	Run()
+}
+
+func TestContext_Spawn(t *testing.T) {
+	rootCtx := RootContext()
+
+	stopped := make(chan string, 3)
+	children := make(chan []*Pid, 1)
+
+	spawnChild := func(ctx *Context, name string) *Pid {
+		return ctx.SpawnWithInit(func(ctx *Context) {
+			ctx.Defer(func() {
+				stopped <- name
+			})
+		}, func(ctx *Context, message Message) {
+		})
+	}
+
+	parent := SpawnWithInit(func(ctx *Context) {
+		first := spawnChild(ctx, "first")
+		assert.True(t, first.Parent().Is(ctx.Self()))
+
+		ctx.SpawnWithInit(func(ctx *Context) {
+			spawnChild(ctx, "grandchild")
+		}, func(ctx *Context, message Message) {
+		})
+
+		spawnChild(ctx, "last")
+	}, func(ctx *Context, message Message) {
+		children <- ctx.Children()
+	})
+
+	rootCtx.Send(parent, EmptyMessage{})
+	assert.Len(t, <-children, 3)
+	assert.Nil(t, parent.Parent())
+
+	rootCtx.Kill(parent)
+
+	//the subtree is stopped in reverse start order
+	assert.Equal(t, "last", <-stopped)
+	assert.Equal(t, "grandchild", <-stopped)
+	assert.Equal(t, "first", <-stopped)
+
+	Run()
+}
+
+func TestContext_SpawnStuckChild(t *testing.T) {
+	rootCtx := RootContext()
+
+	old := childShutdownTimeout
+	childShutdownTimeout = 50 * time.Millisecond
+	defer func() {
+		childShutdownTimeout = old
+	}()
+
+	stuck := make(chan bool)
+	unstuck := make(chan bool)
+
+	parent := SpawnWithInit(func(ctx *Context) {
+		child := ctx.Spawn(func(ctx *Context, message Message) {
+			stuck <- true
+			<-unstuck
+		})
+
+		ctx.Send(child, EmptyMessage{})
+	}, func(ctx *Context, message Message) {
+	})
+
+	<-stuck
+
+	down := make(chan DownMessage, 1)
+
+	SpawnWithInit(func(ctx *Context) {
+		ctx.Monitor(parent)
+	}, func(ctx *Context, message Message) {
+		down <- message.(DownMessage)
+		ctx.Quit()
+	})
+
+	rootCtx.Kill(parent)
+
+	//the parent doesn't wait for the stuck child forever
+	select {
+	case d := <-down:
+		assert.Equal(t, KILLED_EXIT, d.Reason.Kind)
+	case <-time.After(5 * time.Second):
+		t.Fatal("parent didn't go down")
+	}
+
+	close(unstuck)
+
+	Run()
+}
+
+func TestContext_ChildrenPrune(t *testing.T) {
+	rootCtx := RootContext()
+
+	children := make(chan []*Pid, 1)
+
+	parent := SpawnWithInit(func(ctx *Context) {
+		ctx.Spawn(func(ctx *Context, message Message) {
+		})
+
+		short := ctx.Spawn(func(ctx *Context, message Message) {
+			ctx.Quit()
+		})
+
+		ctx.Send(short, EmptyMessage{})
+	}, func(ctx *Context, message Message) {
+		children <- ctx.Children()
+		ctx.Quit()
+	})
+
+	<-time.After(50 * time.Millisecond)
+	rootCtx.Send(parent, EmptyMessage{})
+
+	assert.Len(t, <-children, 1)
+
+	Run()
+}
+
 func TestMonitorDeadPid(t *testing.T) {
 	rootCtx := RootContext()
 
@@ -168,8 +473,9 @@ func TestMonitorDeadPid(t *testing.T) {
 	SpawnWithInit(func(ctx *Context) {
 		ctx.Monitor(p)
 	}, func(ctx *Context, message Message) {
-		switch message.(type) {
+		switch m := message.(type) {
 		case DownMessage:
+			assert.Equal(t, NO_PROC_EXIT, m.Reason.Kind)
 			ctx.Quit()
 		}
 	})
@@ -218,6 +524,1499 @@ func TestContext_SendAfter(t *testing.T) {
 	Run()
 }
 
+func TestContext_SendAfterSenderDown(t *testing.T) {
+	rootCtx := RootContext()
+
+	received := make(chan Message, 1)
+
+	collector := Spawn(func(ctx *Context, message Message) {
+		received <- message
+	})
+
+	alive := SpawnWithInit(func(ctx *Context) {
+		ctx.SendAfter(collector, EmptyMessage{}, 20*time.Millisecond)
+	}, func(ctx *Context, message Message) {
+	})
+
+	sender := SpawnWithInit(func(ctx *Context) {
+		ctx.SendAfter(collector, GenericMessage{Value: "after"}, 20*time.Millisecond)
+	}, func(ctx *Context, message Message) {
+	})
+
+	//the SendAfter of the sender is stopped as soon as it goes down
+	rootCtx.Kill(sender)
+
+	<-time.After(100 * time.Millisecond)
+	assert.Len(t, received, 1)
+	assert.Equal(t, EmptyMessage{}, <-received)
+
+	rootCtx.Kill(alive)
+	rootCtx.Kill(collector)
+
+	Run()
+}
+
+func TestContext_SendInterval(t *testing.T) {
+	rootCtx := RootContext()
+
+	received := make(chan Message, 100)
+
+	collector := Spawn(func(ctx *Context, message Message) {
+		received <- message
+	})
+
+	sender := SpawnWithInit(func(ctx *Context) {
+		ctx.SendInterval(collector, EmptyMessage{}, 10*time.Millisecond)
+	}, func(ctx *Context, message Message) {
+	})
+
+	<-received
+	<-received
+
+	//the interval is stopped as soon as the sender goes down
+	rootCtx.Kill(sender)
+	<-time.After(50 * time.Millisecond)
+
+	for len(received) != 0 {
+		<-received
+	}
+
+	<-time.After(50 * time.Millisecond)
+	assert.Len(t, received, 0)
+
+	rootCtx.Kill(collector)
+
+	Run()
+}
+
+func TestContext_StartTimer(t *testing.T) {
+	received := make(chan Message, 10)
+
+	SpawnWithInit(func(ctx *Context) {
+		ctx.StartTimer("tick", GenericMessage{Value: "first"}, 10*time.Millisecond)
+		//replaces the first timer
+		ctx.StartTimer("tick", GenericMessage{Value: "second"}, 20*time.Millisecond)
+
+		ctx.StartTimer("canceled", GenericMessage{Value: "canceled"}, 10*time.Millisecond)
+		ctx.CancelTimer("canceled")
+
+		ctx.StartTimer("quit", KillMessage{}, 100*time.Millisecond)
+	}, func(ctx *Context, message Message) {
+		if _, ok := message.(KillMessage); ok {
+			ctx.Quit()
+		}
+
+		received <- message
+	})
+
+	Run()
+
+	assert.Len(t, received, 1)
+	assert.Equal(t, GenericMessage{Value: "second"}, <-received)
+}
+
+func TestContext_StartTimerFired(t *testing.T) {
+	received := make(chan Message, 10)
+
+	SpawnWithInit(func(ctx *Context) {
+		ctx.StartTimer("canceled", GenericMessage{Value: "canceled"}, 1*time.Millisecond)
+		ctx.StartTimer("replaced", GenericMessage{Value: "old"}, 1*time.Millisecond)
+
+		//both timers fire (i.e. their messages are in the mailbox) before they're changed
+		<-time.After(20 * time.Millisecond)
+
+		ctx.CancelTimer("canceled")
+		ctx.StartTimer("replaced", GenericMessage{Value: "new"}, 1*time.Millisecond)
+
+		ctx.StartTimer("quit", KillMessage{}, 50*time.Millisecond)
+	}, func(ctx *Context, message Message) {
+		if _, ok := message.(KillMessage); ok {
+			ctx.Quit()
+		}
+
+		received <- message
+	})
+
+	Run()
+
+	assert.Len(t, received, 1)
+	assert.Equal(t, GenericMessage{Value: "new"}, <-received)
+}
+
+func TestContext_SendAfterNotReceived(t *testing.T) {
+	rootCtx := RootContext()
+
+	dead := Spawn(func(ctx *Context, message Message) {
+	})
+	rootCtx.Kill(dead)
+	<-dead.done
+
+	sender := SpawnWithInit(func(ctx *Context) {
+		//the message is never received, the timer is gone anyway
+		ctx.SendAfter(dead, EmptyMessage{}, 1*time.Millisecond)
+		ctx.SendAfter(&Pid{MachineId: "not_connected", Id: "remote"}, EmptyMessage{}, 1*time.Millisecond)
+	}, func(ctx *Context, message Message) {
+	})
+
+	assert.Eventually(t, func() bool {
+		info, _ := ProcessInfo(sender)
+		return len(info.Timers) == 0
+	}, 1*time.Second, 5*time.Millisecond)
+
+	rootCtx.Kill(sender)
+
+	Run()
+}
+
+func TestContext_StartPeriodicTimer(t *testing.T) {
+	count := 0
+
+	SpawnWithInit(func(ctx *Context) {
+		ctx.StartPeriodicTimer("tick", EmptyMessage{}, 10*time.Millisecond)
+	}, func(ctx *Context, message Message) {
+		count++
+
+		if count == 3 {
+			ctx.CancelTimer("tick")
+			ctx.StartTimer("quit", KillMessage{}, 50*time.Millisecond)
+		}
+
+		if _, ok := message.(KillMessage); ok {
+			ctx.Quit()
+		}
+	})
+
+	Run()
+
+	//3 ticks and the KillMessage
+	assert.Equal(t, 4, count)
+}
+
+func TestContext_SetReceiveTimeout(t *testing.T) {
+	rootCtx := RootContext()
+
+	received := make(chan Message, 10)
+
+	p := SpawnWithInit(func(ctx *Context) {
+		ctx.SetReceiveTimeout(100 * time.Millisecond)
+	}, func(ctx *Context, message Message) {
+		received <- message
+
+		if _, ok := message.(ReceiveTimeoutMessage); ok {
+			ctx.Quit()
+		}
+	})
+
+	//every message restarts the timeout
+	for i := 0; i < 5; i++ {
+		<-time.After(50 * time.Millisecond)
+		rootCtx.Send(p, EmptyMessage{})
+	}
+
+	Run()
+
+	assert.Len(t, received, 6)
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, EmptyMessage{}, <-received)
+	}
+
+	assert.Equal(t, ReceiveTimeoutMessage{}, <-received)
+}
+
+func TestContext_SetReceiveTimeoutOff(t *testing.T) {
+	rootCtx := RootContext()
+
+	count := 0
+
+	p := SpawnWithInit(func(ctx *Context) {
+		ctx.SetReceiveTimeout(10 * time.Millisecond)
+	}, func(ctx *Context, message Message) {
+		switch message.(type) {
+		case ReceiveTimeoutMessage:
+			count++
+			ctx.SetReceiveTimeout(0)
+		case KillMessage:
+			ctx.Quit()
+		}
+	})
+
+	<-time.After(100 * time.Millisecond)
+	rootCtx.Send(p, KillMessage{})
+
+	Run()
+
+	assert.Equal(t, 1, count)
+}
+
+func TestContext_Become(t *testing.T) {
+	rootCtx := RootContext()
+
+	received := make(chan string, 10)
+
+	var closed func(ctx *Context, message Message)
+	opened := func(ctx *Context, message Message) {
+		received <- "opened"
+		ctx.Become(closed)
+	}
+	closed = func(ctx *Context, message Message) {
+		received <- "closed"
+		ctx.Become(opened)
+	}
+
+	p := SpawnWithInit(func(ctx *Context) {
+		ctx.Become(closed)
+	}, func(ctx *Context, message Message) {
+		t.Fail()
+	})
+
+	for i := 0; i < 4; i++ {
+		rootCtx.Send(p, EmptyMessage{})
+	}
+
+	assert.Equal(t, "closed", <-received)
+	assert.Equal(t, "opened", <-received)
+	assert.Equal(t, "closed", <-received)
+	assert.Equal(t, "opened", <-received)
+
+	rootCtx.Kill(p)
+
+	Run()
+}
+
+type becomeTestActor struct {
+	received chan string
+}
+
+func (b *becomeTestActor) Init(ctx *Context) {
+}
+
+func (b *becomeTestActor) Run(ctx *Context, message Message) {
+	b.received <- "run"
+
+	ctx.BecomeStacked(func(ctx *Context, message Message) {
+		b.received <- "first"
+
+		ctx.BecomeStacked(func(ctx *Context, message Message) {
+			b.received <- "second"
+			ctx.Unbecome()
+		})
+	})
+}
+
+func TestContext_BecomeStacked(t *testing.T) {
+	rootCtx := RootContext()
+
+	received := make(chan string, 10)
+
+	p := SpawnStateful(&becomeTestActor{received: received})
+
+	for i := 0; i < 4; i++ {
+		rootCtx.Send(p, EmptyMessage{})
+	}
+
+	assert.Equal(t, "run", <-received)
+	assert.Equal(t, "first", <-received)
+	assert.Equal(t, "second", <-received)
+	//Unbecome returns to the previous behavior
+	assert.Equal(t, "first", <-received)
+
+	rootCtx.Send(p, GenericMessage{})
+	assert.Equal(t, "second", <-received)
+
+	rootCtx.Kill(p)
+
+	Run()
+}
+
+func TestContext_UnbecomeAll(t *testing.T) {
+	rootCtx := RootContext()
+
+	received := make(chan string, 10)
+
+	p := SpawnWithInit(func(ctx *Context) {
+		ctx.BecomeStacked(func(ctx *Context, message Message) {
+			received <- "first"
+		})
+		ctx.BecomeStacked(func(ctx *Context, message Message) {
+			received <- "second"
+			ctx.UnbecomeAll()
+		})
+	}, func(ctx *Context, message Message) {
+		received <- "run"
+	})
+
+	rootCtx.Send(p, EmptyMessage{})
+	rootCtx.Send(p, EmptyMessage{})
+
+	assert.Equal(t, "second", <-received)
+	assert.Equal(t, "run", <-received)
+
+	rootCtx.Kill(p)
+
+	Run()
+}
+
+func TestContext_Register(t *testing.T) {
+	rootCtx := RootContext()
+
+	received := make(chan Message, 1)
+	errs := make(chan error, 1)
+
+	p := SpawnWithInit(func(ctx *Context) {
+		assert.Nil(t, ctx.Register("registered"))
+	}, func(ctx *Context, message Message) {
+		received <- message
+		ctx.Quit()
+	})
+
+	pid, ok := Whereis("registered")
+	assert.True(t, ok)
+	assert.True(t, pid.Is(p))
+
+	//the name is already taken
+	other := SpawnWithInit(func(ctx *Context) {
+		errs <- ctx.Register("registered")
+	}, func(ctx *Context, message Message) {
+	})
+
+	assert.Equal(t, ErrNameTaken, <-errs)
+
+	assert.Nil(t, rootCtx.SendNamed("registered", GenericMessage{Value: "hello"}))
+	assert.Equal(t, GenericMessage{Value: "hello"}, <-received)
+
+	<-time.After(50 * time.Millisecond)
+
+	//the name is removed as soon as the actor goes down
+	_, ok = Whereis("registered")
+	assert.False(t, ok)
+	assert.Equal(t, ErrNameNotFound, rootCtx.SendNamed("registered", EmptyMessage{}))
+
+	rootCtx.Kill(other)
+
+	Run()
+}
+
+func TestContext_MonitorNamed(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := SpawnWithInit(func(ctx *Context) {
+		_ = ctx.Register("monitored")
+	}, func(ctx *Context, message Message) {
+		ctx.Quit()
+	})
+
+	downs := make(chan DownMessage, 1)
+	errs := make(chan error, 1)
+
+	SpawnWithInit(func(ctx *Context) {
+		_, err := ctx.MonitorNamed("monitored")
+		assert.Nil(t, err)
+
+		_, err = ctx.MonitorNamed("not registered")
+		errs <- err
+	}, func(ctx *Context, message Message) {
+		if m, ok := message.(DownMessage); ok {
+			downs <- m
+			ctx.Quit()
+		}
+	})
+
+	assert.Equal(t, ErrNameNotFound, <-errs)
+
+	rootCtx.Send(p, EmptyMessage{})
+	assert.True(t, (<-downs).Who.Is(p))
+
+	Run()
+}
+
+func TestContext_RegisterGlobal(t *testing.T) {
+	rootCtx := RootContext()
+
+	received := make(chan Message, 1)
+
+	p := SpawnWithInit(func(ctx *Context) {
+		assert.Nil(t, ctx.RegisterGlobal("global"))
+		assert.Equal(t, ErrNameTaken, ctx.RegisterGlobal("global"))
+	}, func(ctx *Context, message Message) {
+		received <- message
+		ctx.Quit()
+	})
+
+	pid, ok := WhereisGlobal("global")
+	assert.True(t, ok)
+	assert.True(t, pid.Is(p))
+
+	assert.Nil(t, rootCtx.SendGlobal("global", GenericMessage{Value: "hello"}))
+	assert.Equal(t, GenericMessage{Value: "hello"}, <-received)
+
+	Run()
+
+	_, ok = WhereisGlobal("global")
+	assert.False(t, ok)
+}
+
+func TestGlobalNameConflict(t *testing.T) {
+	RootContext()
+
+	register := func(name string, machine string, id string) {
+		handleGpRequest(qpmd.Request{
+			RequestType: globalRegisterMessageType,
+			Data: map[string]interface{}{
+				nameVal: name,
+				pidVal: map[string]interface{}{
+					"MachineId": machine,
+					"Id":        id,
+				},
+			},
+		}, "test")
+	}
+
+	register("conflict", "machine_b", "pid")
+	register("conflict", "machine_a", "pid")
+	register("conflict", "machine_c", "pid")
+
+	//every machine has to come to the same conclusion, no matter the order
+	pid, ok := WhereisGlobal("conflict")
+	assert.True(t, ok)
+	assert.Equal(t, "machine_a", pid.MachineId)
+
+	SetGlobalConflictPolicy(func(name string, existing *Pid, incoming *Pid) *Pid {
+		return incoming
+	})
+	defer SetGlobalConflictPolicy(KeepLowestPid)
+
+	register("conflict", "machine_c", "pid")
+	pid, _ = WhereisGlobal("conflict")
+	assert.Equal(t, "machine_c", pid.MachineId)
+
+	//names of a machine that disconnected are removed
+	removeGlobalNamesOfMachine("machine_c")
+	_, ok = WhereisGlobal("conflict")
+	assert.False(t, ok)
+}
+
+func TestSyncGlobalNamesBlocked(t *testing.T) {
+	rootCtx := RootContext()
+
+	registered := make(chan bool)
+
+	p := SpawnWithInit(func(ctx *Context) {
+		assert.Nil(t, ctx.RegisterGlobal("sync_a"))
+		assert.Nil(t, ctx.RegisterGlobal("sync_b"))
+	}, func(ctx *Context, message Message) {
+		assert.Nil(t, ctx.RegisterGlobal("sync_c"))
+		registered <- true
+	})
+
+	//a machine that doesn't read its requests anymore
+	globalNameChan := make(chan remoteGlobalNameTuple, 1)
+	stuck := &Machine{MachineId: "stuck", globalNameChan: globalNameChan}
+
+	synced := make(chan bool)
+	go func() {
+		syncGlobalNames(stuck)
+		synced <- true
+	}()
+
+	<-time.After(20 * time.Millisecond)
+
+	rootCtx.Send(p, EmptyMessage{})
+
+	select {
+	case <-registered:
+	case <-time.After(1 * time.Second):
+		t.Fatal("RegisterGlobal was blocked by a machine that doesn't read")
+	}
+
+	for i := 0; i < 2; i++ {
+		<-globalNameChan
+	}
+	<-synced
+
+	rootCtx.Kill(p)
+
+	Run()
+}
+
+func TestContext_Join(t *testing.T) {
+	rootCtx := RootContext()
+
+	received := make(chan Message, 4)
+	joined := make(chan bool)
+
+	member := func(ctx *Context, message Message) {
+		switch message {
+		case GenericMessage{Value: "join"}:
+			ctx.Join("invalidators")
+			joined <- true
+		case GenericMessage{Value: "leave"}:
+			ctx.Leave("invalidators")
+			joined <- true
+		default:
+			received <- message
+		}
+	}
+
+	a := Spawn(member)
+	b := Spawn(member)
+
+	rootCtx.Send(a, GenericMessage{Value: "join"})
+	<-joined
+	rootCtx.Send(b, GenericMessage{Value: "join"})
+	<-joined
+
+	//joining twice doesn't add the actor twice
+	rootCtx.Send(b, GenericMessage{Value: "join"})
+	<-joined
+
+	assert.Len(t, Members("invalidators"), 2)
+	assert.Len(t, LocalMembers("invalidators"), 2)
+
+	rootCtx.Broadcast("invalidators", GenericMessage{Value: "invalidate"})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case m := <-received:
+			assert.Equal(t, GenericMessage{Value: "invalidate"}, m)
+		case <-time.After(1 * time.Second):
+			assert.Fail(t, "broadcast wasn't received by all members")
+		}
+	}
+
+	rootCtx.Send(a, GenericMessage{Value: "leave"})
+	<-joined
+	assert.Equal(t, []*Pid{b}, Members("invalidators"))
+
+	//members leave all groups when they go down
+	rootCtx.Kill(b)
+	<-b.done
+	assert.Len(t, Members("invalidators"), 0)
+
+	rootCtx.Kill(a)
+}
+
+func TestSyncGroupsBlocked(t *testing.T) {
+	rootCtx := RootContext()
+
+	joined := make(chan bool)
+
+	p := SpawnWithInit(func(ctx *Context) {
+		ctx.Join("sync_a")
+		ctx.Join("sync_b")
+	}, func(ctx *Context, message Message) {
+		ctx.Join("sync_c")
+		joined <- true
+	})
+
+	//a machine that doesn't read its requests anymore
+	groupChan := make(chan remoteGroupTuple, 1)
+	stuck := &Machine{MachineId: "stuck", groupChan: groupChan}
+
+	synced := make(chan bool)
+	go func() {
+		syncGroups(stuck)
+		synced <- true
+	}()
+
+	<-time.After(20 * time.Millisecond)
+
+	rootCtx.Send(p, EmptyMessage{})
+
+	select {
+	case <-joined:
+	case <-time.After(1 * time.Second):
+		t.Fatal("Join was blocked by a machine that doesn't read")
+	}
+
+	for i := 0; i < 2; i++ {
+		<-groupChan
+	}
+	<-synced
+
+	rootCtx.Kill(p)
+
+	Run()
+}
+
+func TestRemoteGroupMembership(t *testing.T) {
+	RootContext()
+
+	membership := func(requestType qpmd.RequestType, machine string) {
+		handleGpRequest(qpmd.Request{
+			RequestType: requestType,
+			Data: map[string]interface{}{
+				groupVal: "remote_group",
+				pidVal: map[string]interface{}{
+					"MachineId": machine,
+					"Id":        "pid",
+				},
+			},
+		}, "test")
+	}
+
+	membership(groupJoinMessageType, "machine_a")
+	membership(groupJoinMessageType, "machine_b")
+
+	assert.Len(t, Members("remote_group"), 2)
+	assert.Len(t, LocalMembers("remote_group"), 0)
+
+	membership(groupLeaveMessageType, "machine_a")
+	assert.Len(t, Members("remote_group"), 1)
+
+	//members of a machine that disconnected are removed
+	removeGroupMembersOfMachine("machine_b")
+	assert.Len(t, Members("remote_group"), 0)
+}
+
+func TestProcessInfo(t *testing.T) {
+	rootCtx := RootContext()
+
+	processing := make(chan bool)
+	proceed := make(chan bool)
+
+	monitored := Spawn(func(ctx *Context, message Message) {
+	})
+
+	pid := SpawnWithInit(func(ctx *Context) {
+		_ = ctx.Register("info_test")
+		ctx.Monitor(monitored)
+		ctx.StartTimer("tick", GenericMessage{}, 1*time.Minute)
+	}, func(ctx *Context, message Message) {
+		if message == (GenericMessage{Value: "block"}) {
+			ctx.Send(monitored, GenericMessage{})
+			processing <- true
+			<-proceed
+		}
+	})
+
+	rootCtx.Send(pid, GenericMessage{Value: "block"})
+	<-processing
+	rootCtx.Send(pid, GenericMessage{})
+
+	assert.Eventually(t, func() bool {
+		info, _ := ProcessInfo(pid)
+		return info.MailboxLen == 1
+	}, 1*time.Second, 10*time.Millisecond)
+
+	info, err := ProcessInfo(pid)
+	assert.NoError(t, err)
+	assert.True(t, info.Alive)
+	assert.Equal(t, []string{"info_test"}, info.Names)
+	assert.Equal(t, []*Pid{monitored}, info.Monitored)
+	assert.Equal(t, []string{"tick"}, info.Timers)
+	assert.Equal(t, uint64(1), info.Received)
+	assert.Equal(t, uint64(1), info.Sent)
+	assert.Equal(t, GenericMessage{}.Type(), info.CurrentMessage)
+	assert.False(t, info.SpawnedAt.IsZero())
+
+	info, _ = ProcessInfo(monitored)
+	assert.Equal(t, []*Pid{pid}, info.Monitors)
+
+	proceed <- true
+
+	rootCtx.Kill(pid)
+	<-pid.done
+
+	info, err = ProcessInfo(pid)
+	assert.NoError(t, err)
+	assert.False(t, info.Alive)
+
+	rootCtx.Kill(monitored)
+}
+
+func TestRemoteProcessInfo(t *testing.T) {
+	RootContext()
+
+	_, err := ProcessInfo(&Pid{MachineId: "not_connected", Id: "pid"})
+	assert.Equal(t, ErrNoConnection, err)
+
+	resultChan := make(chan ActorInfo, 1)
+
+	processInfoRequestsMu.Lock()
+	processInfoRequests["request"] = resultChan
+	processInfoRequestsMu.Unlock()
+
+	sent := ActorInfo{
+		Pid:            &Pid{MachineId: "machine", Id: "pid"},
+		Alive:          true,
+		MailboxLen:     3,
+		Names:          []string{"name"},
+		Monitors:       []*Pid{{MachineId: "machine", Id: "monitor"}},
+		Timers:         []string{"tick"},
+		SpawnedAt:      time.Now().Truncate(time.Second),
+		Received:       5,
+		CurrentMessage: "generic",
+	}
+
+	//the result goes over the wire just like any other general purpose request
+	b, err := msgpack.Marshal(qpmd.Request{
+		RequestType: processInfoResultMessageType,
+		Data: map[string]interface{}{
+			requestIdVal: "request",
+			infoVal:      &sent,
+		},
+	})
+	assert.NoError(t, err)
+
+	req := qpmd.Request{}
+	assert.NoError(t, msgpack.Unmarshal(b, &req))
+
+	handleGpRequest(req, "test")
+
+	//the request is replayed after a reconnect, a second result must not block
+	handleGpRequest(req, "test")
+
+	received := <-resultChan
+	assert.Equal(t, sent.Pid.String(), received.Pid.String())
+	assert.True(t, received.Alive)
+	assert.Equal(t, 3, received.MailboxLen)
+	assert.Equal(t, []string{"name"}, received.Names)
+	assert.Equal(t, "monitor", received.Monitors[0].Id)
+	assert.Equal(t, []string{"tick"}, received.Timers)
+	assert.True(t, sent.SpawnedAt.Equal(received.SpawnedAt))
+	assert.Equal(t, uint64(5), received.Received)
+	assert.Equal(t, "generic", received.CurrentMessage)
+
+	processInfoRequestsMu.Lock()
+	delete(processInfoRequests, "request")
+	processInfoRequestsMu.Unlock()
+}
+
+func TestContext_Receive(t *testing.T) {
+	rootCtx := RootContext()
+
+	testChan := make(chan string, 3)
+
+	p := Spawn(func(ctx *Context, message Message) {
+		switch m := message.(type) {
+		case KillMessage:
+			_, err := ctx.Receive(MatchType(EmptyMessage{}), 1*time.Second)
+			assert.NoError(t, err)
+			testChan <- "Empty"
+		case GenericMessage:
+			testChan <- m.Value.(string)
+
+			if m.Value == "Bar" {
+				ctx.Quit()
+			}
+		}
+	})
+
+	rootCtx.Send(p, KillMessage{})
+	rootCtx.Send(p, GenericMessage{Value: "Foo"})
+	rootCtx.Send(p, GenericMessage{Value: "Bar"})
+	rootCtx.Send(p, EmptyMessage{})
+
+	assert.Equal(t, "Empty", <-testChan)
+	assert.Equal(t, "Foo", <-testChan)
+	assert.Equal(t, "Bar", <-testChan)
+
+	Run()
+}
+
+func TestContext_ReceiveTimeout(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := Spawn(func(ctx *Context, message Message) {
+		_, err := ctx.Receive(MatchType(EmptyMessage{}), 50*time.Millisecond)
+		assert.Equal(t, ErrReceiveTimeout, err)
+		ctx.Quit()
+	})
+
+	rootCtx.Send(p, KillMessage{})
+
+	Run()
+}
+
+func TestContext_Stash(t *testing.T) {
+	rootCtx := RootContext()
+
+	testChan := make(chan string, 3)
+	initialized := false
+
+	p := Spawn(func(ctx *Context, message Message) {
+		switch m := message.(type) {
+		case EmptyMessage:
+			initialized = true
+			ctx.UnstashAll()
+		case GenericMessage:
+			if !initialized {
+				assert.NoError(t, ctx.Stash())
+				return
+			}
+
+			testChan <- m.Value.(string)
+
+			if m.Value == "Baz" {
+				ctx.Quit()
+			}
+		}
+	})
+
+	rootCtx.Send(p, GenericMessage{Value: "Foo"})
+	rootCtx.Send(p, GenericMessage{Value: "Bar"})
+	rootCtx.Send(p, EmptyMessage{})
+	rootCtx.Send(p, GenericMessage{Value: "Baz"})
+
+	assert.Equal(t, "Foo", <-testChan)
+	assert.Equal(t, "Bar", <-testChan)
+	assert.Equal(t, "Baz", <-testChan)
+
+	Run()
+}
+
+func TestContext_StashKeepsCurrentMessage(t *testing.T) {
+	RootContext()
+
+	replies := make(chan Message, 1)
+
+	p := Spawn(func(ctx *Context, message Message) {
+		assert.NoError(t, ctx.Stash())
+		assert.Error(t, ctx.Stash())
+
+		//the message is still the one being processed
+		assert.NotNil(t, ctx.Sender())
+		assert.True(t, ctx.Reply(EmptyMessage{}))
+
+		ctx.Quit()
+	})
+
+	SpawnWithInit(func(ctx *Context) {
+		ctx.Send(p, GenericMessage{Value: "Foo"})
+	}, func(ctx *Context, message Message) {
+		replies <- message
+		ctx.Quit()
+	})
+
+	select {
+	case m := <-replies:
+		assert.Equal(t, EmptyMessage{}, m)
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't receive reply")
+	}
+
+	Run()
+}
+
+func TestContext_StashFull(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := SpawnWithInit(func(ctx *Context) {
+		ctx.SetStashCapacity(1)
+	}, func(ctx *Context, message Message) {
+		switch message.(type) {
+		case GenericMessage:
+			assert.NoError(t, ctx.Stash())
+		case EmptyMessage:
+			assert.Equal(t, ErrStashFull, ctx.Stash())
+			ctx.Quit()
+		}
+	})
+
+	rootCtx.Send(p, GenericMessage{})
+	rootCtx.Send(p, EmptyMessage{})
+
+	Run()
+}
+
+func TestContext_Ask(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := Spawn(func(ctx *Context, message Message) {
+		if m, ok := message.(GenericMessage); ok {
+			ctx.Reply(GenericMessage{Value: m.Value.(string) + " world"})
+		}
+
+		ctx.Quit()
+	})
+
+	res, err := rootCtx.Ask(p, GenericMessage{Value: "hello"}, 1*time.Second).Await()
+
+	assert.Nil(t, err)
+	assert.Equal(t, GenericMessage{Value: "hello world"}, res)
+
+	Run()
+}
+
+func TestContext_AskTimeout(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := Spawn(func(ctx *Context, message Message) {
+		//never reply
+	})
+
+	_, err := rootCtx.Ask(p, EmptyMessage{}, 50*time.Millisecond).Await()
+	assert.Equal(t, ErrAskTimeout, err)
+
+	rootCtx.Kill(p)
+
+	Run()
+}
+
+func TestContext_AskNoTimeout(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := Spawn(func(ctx *Context, message Message) {
+		<-time.After(50 * time.Millisecond)
+		ctx.Reply(message)
+		ctx.Quit()
+	})
+
+	res, err := rootCtx.Ask(p, EmptyMessage{}, 0).Await()
+
+	assert.Nil(t, err)
+	assert.Equal(t, EmptyMessage{}, res)
+
+	Run()
+}
+
+func TestContext_AskDeadPid(t *testing.T) {
+	rootCtx := RootContext()
+
+	p := Spawn(func(ctx *Context, message Message) {
+		ctx.Quit()
+	})
+
+	_, err := rootCtx.Ask(p, EmptyMessage{}, 1*time.Second).Await()
+	assert.Equal(t, ErrAskTargetDown, err)
+
+	Run()
+}
+
+func TestContext_AskRemote(t *testing.T) {
+	rootCtx := RootContext()
+
+	r := startNode(t)
+
+	echo, err := r.Remote("echo")
+	assert.NoError(t, err)
+
+	res, err := rootCtx.Ask(echo, GenericMessage{Value: "hello"}, 5*time.Second).Await()
+	assert.NoError(t, err)
+	assert.Equal(t, GenericMessage{Value: "hello"}, res)
+
+	//an actor that asks doesn't receive the reply itself
+	results := make(chan Message, 1)
+
+	p := Spawn(func(ctx *Context, message Message) {
+		res, err := ctx.Ask(echo, message, 5*time.Second).Await()
+		assert.NoError(t, err)
+
+		results <- res
+		ctx.Quit()
+	})
+
+	rootCtx.Send(p, GenericMessage{Value: "from an actor"})
+	assert.Equal(t, GenericMessage{Value: "from an actor"}, <-results)
+
+	quitter, err := r.Remote("quitter")
+	assert.NoError(t, err)
+
+	_, err = rootCtx.Ask(quitter, GenericMessage{}, 5*time.Second).Await()
+	assert.Equal(t, ErrAskTargetDown, err)
+
+	Run()
+}
+
+func TestFuture_PipeTo(t *testing.T) {
+	rootCtx := RootContext()
+
+	results := make(chan FutureResultMessage, 1)
+
+	p := Spawn(func(ctx *Context, message Message) {
+		switch m := message.(type) {
+		case GenericMessage:
+			if m.Value == "ask yourself" {
+				//asking ourselves and awaiting the future would block the actor
+				ctx.Ask(ctx.Self(), GenericMessage{Value: "question"}, 1*time.Second).PipeTo(ctx.Self())
+				return
+			}
+
+			ctx.Reply(GenericMessage{Value: "answer"})
+		case FutureResultMessage:
+			results <- m
+			ctx.Quit()
+		}
+	})
+
+	rootCtx.Send(p, GenericMessage{Value: "ask yourself"})
+
+	res := <-results
+	assert.Empty(t, res.Error)
+	assert.Equal(t, GenericMessage{Value: "answer"}, res.Response)
+
+	Run()
+}
+
+func TestContext_Sender(t *testing.T) {
+	rootCtx := RootContext()
+
+	senders := make(chan *Pid, 2)
+
+	receiver := Spawn(func(ctx *Context, message Message) {
+		senders <- ctx.Sender()
+	})
+
+	sender := SpawnWithInit(func(ctx *Context) {
+		ctx.Send(receiver, EmptyMessage{})
+	}, func(ctx *Context, message Message) {
+	})
+
+	assert.True(t, (<-senders).Is(sender))
+
+	//messages sent with a RootContext don't have a sender
+	rootCtx.Send(receiver, EmptyMessage{})
+	assert.Nil(t, <-senders)
+
+	rootCtx.Kill(receiver)
+	rootCtx.Kill(sender)
+
+	Run()
+}
+
+func TestMessageGatewaySender(t *testing.T) {
+	RootContext()
+
+	senders := make(chan *Pid, 1)
+
+	receiver := Spawn(func(ctx *Context, message Message) {
+		senders <- ctx.Sender()
+		ctx.Quit()
+	})
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", messageGatewayPort))
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	assert.Nil(t, clientHandshake(conn, map[string]interface{}{}))
+
+	b, err := encodeRemoteMessage(remoteMessageTuple{
+		To:      receiver,
+		Message: GenericMessage{Value: "hello"},
+		Sender:  &Pid{MachineId: "remote", Id: "sender"},
+	})
+	assert.Nil(t, err)
+
+	err = writeFrame(conn, b)
+	assert.Nil(t, err)
+
+	sender := <-senders
+	assert.Equal(t, "remote", sender.MachineId)
+	assert.Equal(t, "sender", sender.Id)
+
+	Run()
+}
+
+func TestBoundedMailbox(t *testing.T) {
+	rootCtx := RootContext()
+
+	block := make(chan bool)
+
+	p := SpawnWithOptions(func(ctx *Context, message Message) {
+		switch message.(type) {
+		case KillMessage:
+			<-block
+		case EmptyMessage:
+			ctx.Quit()
+		}
+	}, WithBoundedMailbox(1, mailbox.FAIL_POLICY))
+
+	assert.NoError(t, rootCtx.TrySend(p, KillMessage{}))
+
+	<-time.After(10 * time.Millisecond)
+
+	assert.NoError(t, rootCtx.TrySend(p, EmptyMessage{}))
+	assert.Equal(t, mailbox.ErrFull, rootCtx.TrySend(p, EmptyMessage{}))
+
+	block <- true
+
+	Run()
+}
+
+type priorityTestMessage struct {
+}
+
+func (p priorityTestMessage) Type() string {
+	return "priorityTestMessage"
+}
+
+func (p priorityTestMessage) Priority() bool {
+	return true
+}
+
+func TestPriorityMailbox(t *testing.T) {
+	rootCtx := RootContext()
+
+	block := make(chan bool)
+	testChan := make(chan string, 3)
+
+	p := SpawnStatefulWithOptions(&StatelessActor{
+		ReceiveFunction: func(ctx *Context, message Message) {
+			switch m := message.(type) {
+			case GenericMessage:
+				if m.Value == "block" {
+					<-block
+					return
+				}
+
+				testChan <- m.Value.(string)
+			case priorityTestMessage:
+				testChan <- "priority"
+			}
+		},
+	}, WithPriorityMailbox())
+
+	rootCtx.Send(p, GenericMessage{Value: "block"})
+	rootCtx.Send(p, GenericMessage{Value: "Foo"})
+	rootCtx.Send(p, GenericMessage{Value: "Bar"})
+	rootCtx.Send(p, priorityTestMessage{})
+
+	block <- true
+
+	assert.Equal(t, "priority", <-testChan)
+	assert.Equal(t, "Foo", <-testChan)
+	assert.Equal(t, "Bar", <-testChan)
+
+	rootCtx.Send(p, PoisonPill{})
+
+	Run()
+}
+
+func TestLockFreeMailbox(t *testing.T) {
+	rootCtx := RootContext()
+
+	testChan := make(chan string, 3)
+
+	p := SpawnStatefulWithOptions(&StatelessActor{
+		ReceiveFunction: func(ctx *Context, message Message) {
+			switch m := message.(type) {
+			case KillMessage:
+				_, err := ctx.Receive(MatchType(EmptyMessage{}), 1*time.Second)
+				assert.NoError(t, err)
+				testChan <- "Empty"
+			case GenericMessage:
+				testChan <- m.Value.(string)
+			}
+		},
+	}, WithLockFreeMailbox())
+
+	rootCtx.Send(p, KillMessage{})
+	rootCtx.Send(p, GenericMessage{Value: "Foo"})
+	rootCtx.Send(p, GenericMessage{Value: "Bar"})
+	rootCtx.Send(p, EmptyMessage{})
+	rootCtx.Send(p, PoisonPill{})
+
+	assert.Equal(t, "Empty", <-testChan)
+	assert.Equal(t, "Foo", <-testChan)
+	assert.Equal(t, "Bar", <-testChan)
+
+	//lock-free mailboxes work on a dispatcher as well
+	dispatched := SpawnStatefulWithOptions(&StatelessActor{
+		ReceiveFunction: func(ctx *Context, message Message) {
+			testChan <- message.(GenericMessage).Value.(string)
+		},
+	}, WithLockFreeMailbox(), WithDispatcher(NewDispatcher("lock_free", 1, 0)))
+
+	rootCtx.Send(dispatched, GenericMessage{Value: "Baz"})
+	assert.Equal(t, "Baz", <-testChan)
+
+	rootCtx.Kill(dispatched)
+
+	Run()
+}
+
+func TestDispatcher(t *testing.T) {
+	rootCtx := RootContext()
+
+	dispatcher := NewDispatcher("test", 2, 5)
+
+	const actors = 100
+	const messages = 50
+
+	done := make(chan bool, actors)
+
+	for i := 0; i < actors; i++ {
+		next := 0
+
+		p := SpawnStatefulWithOptions(&StatelessActor{
+			ReceiveFunction: func(ctx *Context, message Message) {
+				//messages from one sender have to arrive in order
+				assert.Equal(t, next, message.(GenericMessage).Value)
+				next++
+
+				if next == messages {
+					done <- true
+					ctx.Quit()
+				}
+			},
+		}, WithDispatcher(dispatcher))
+
+		for j := 0; j < messages; j++ {
+			rootCtx.Send(p, GenericMessage{Value: j})
+		}
+	}
+
+	for i := 0; i < actors; i++ {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "dispatched actors didn't process all messages")
+		}
+	}
+
+	Run()
+}
+
+func TestDispatcherFairness(t *testing.T) {
+	rootCtx := RootContext()
+
+	dispatcher := NewDispatcher("fairness", 1, 1)
+
+	processed := make(chan string, 4)
+	proceed := make(chan bool)
+
+	busy := SpawnStatefulWithOptions(&StatelessActor{
+		ReceiveFunction: func(ctx *Context, message Message) {
+			if message == (GenericMessage{Value: "block"}) {
+				<-proceed
+			}
+
+			processed <- "busy"
+		},
+	}, WithDispatcher(dispatcher))
+
+	rootCtx.Send(busy, GenericMessage{Value: "block"})
+	rootCtx.Send(busy, EmptyMessage{})
+	rootCtx.Send(busy, EmptyMessage{})
+
+	other := SpawnStatefulWithOptions(&StatelessActor{
+		ReceiveFunction: func(ctx *Context, message Message) {
+			processed <- "other"
+		},
+	}, WithDispatcher(dispatcher))
+
+	rootCtx.Send(other, EmptyMessage{})
+
+	proceed <- true
+
+	//the busy actor has to make room after every message (throughput 1)
+	assert.Equal(t, "busy", <-processed)
+	assert.Equal(t, "other", <-processed)
+	assert.Equal(t, "busy", <-processed)
+	assert.Equal(t, "busy", <-processed)
+
+	rootCtx.Kill(busy)
+	rootCtx.Kill(other)
+
+	Run()
+}
+
+func TestDispatcherSystemRequests(t *testing.T) {
+	rootCtx := RootContext()
+
+	dispatcher := NewDispatcher("system", 2, 0)
+
+	received := make(chan Message, 2)
+
+	p := SpawnStatefulWithOptions(&StatelessActor{
+		ReceiveFunction: func(ctx *Context, message Message) {
+			switch message.(type) {
+			case KillMessage:
+				m, err := ctx.Receive(MatchType(EmptyMessage{}), 1*time.Second)
+				assert.NoError(t, err)
+				received <- m
+			case GenericMessage:
+				received <- message
+			}
+		},
+	}, WithDispatcher(dispatcher))
+
+	reasons := make(chan ExitReason, 1)
+
+	monitor := SpawnWithInit(func(ctx *Context) {
+		ctx.Monitor(p)
+	}, func(ctx *Context, message Message) {
+		if d, ok := message.(DownMessage); ok {
+			reasons <- d.Reason
+			ctx.Quit()
+		}
+	})
+
+	//give the monitor request some time to get through
+	<-time.After(50 * time.Millisecond)
+
+	info, _ := ProcessInfo(p)
+	assert.Equal(t, []*Pid{monitor}, info.Monitors)
+
+	rootCtx.Send(p, KillMessage{})
+	rootCtx.Send(p, GenericMessage{Value: "Foo"})
+	rootCtx.Send(p, EmptyMessage{})
+
+	//Receive skips messages that don't match
+	assert.Equal(t, EmptyMessage{}, <-received)
+	assert.Equal(t, GenericMessage{Value: "Foo"}, <-received)
+
+	rootCtx.Kill(p)
+	assert.Equal(t, KILLED_EXIT, (<-reasons).Kind)
+
+	//links and receive timeouts work just like they do with goroutine actors
+	exits := make(chan ExitMessage, 1)
+
+	trapping := SpawnStatefulWithOptions(&StatelessActor{
+		InitFunction: func(ctx *Context) {
+			ctx.TrapExits(true)
+		},
+		ReceiveFunction: func(ctx *Context, message Message) {
+			if e, ok := message.(ExitMessage); ok {
+				exits <- e
+				ctx.Quit()
+			}
+		},
+	}, WithDispatcher(dispatcher))
+
+	failing := SpawnStatefulWithOptions(&StatelessActor{
+		InitFunction: func(ctx *Context) {
+			ctx.Link(trapping)
+			ctx.SetReceiveTimeout(50 * time.Millisecond)
+		},
+		ReceiveFunction: func(ctx *Context, message Message) {
+			if _, ok := message.(ReceiveTimeoutMessage); ok {
+				panic("timed out")
+			}
+		},
+	}, WithDispatcher(dispatcher))
+
+	e := <-exits
+	assert.True(t, failing.Is(e.Who))
+	assert.Equal(t, PANIC_EXIT, e.Reason.Kind)
+	assert.Equal(t, "timed out", e.Reason.Value)
+
+	Run()
+}
+
+func TestPinnedDispatcher(t *testing.T) {
+	rootCtx := RootContext()
+
+	dispatcher := NewPinnedDispatcher("pinned", 0)
+
+	p := SpawnStatefulWithOptions(&StatelessActor{
+		ReceiveFunction: func(ctx *Context, message Message) {
+			ctx.Reply(message)
+		},
+	}, WithDispatcher(dispatcher))
+
+	res, err := rootCtx.Ask(p, GenericMessage{Value: "Foo"}, 1*time.Second).Await()
+	assert.NoError(t, err)
+	assert.Equal(t, GenericMessage{Value: "Foo"}, res)
+
+	rootCtx.Send(p, PoisonPill{})
+
+	Run()
+}
+
+func TestDispatcherClose(t *testing.T) {
+	rootCtx := RootContext()
+
+	const workers = 50
+
+	dispatcher := NewDispatcher("close", workers, 0)
+
+	p := SpawnStatefulWithOptions(&StatelessActor{
+		ReceiveFunction: func(ctx *Context, message Message) {
+			ctx.Reply(message)
+			ctx.Quit()
+		},
+	}, WithDispatcher(dispatcher))
+
+	res, err := rootCtx.Ask(p, GenericMessage{Value: "Foo"}, 1*time.Second).Await()
+	assert.NoError(t, err)
+	assert.Equal(t, GenericMessage{Value: "Foo"}, res)
+
+	Run()
+
+	running := runtime.NumGoroutine()
+
+	dispatcher.Close()
+	dispatcher.Close()
+
+	//the workers of the dispatcher go down
+	deadline := time.Now().Add(1 * time.Second)
+	for runtime.NumGoroutine() > running-workers && time.Now().Before(deadline) {
+		<-time.After(10 * time.Millisecond)
+	}
+
+	assert.LessOrEqual(t, runtime.NumGoroutine(), running-workers)
+
+	assert.Panics(t, func() {
+		SpawnStatefulWithOptions(&StatelessActor{}, WithDispatcher(dispatcher))
+	})
+}
+
+func benchmarkSend(b *testing.B, send func(to *Pid, m localMessage), options ...SpawnOption) {
+	done := make(chan bool)
+	count := 0
+
+	p := SpawnStatefulWithOptions(&StatelessActor{
+		ReceiveFunction: func(ctx *Context, message Message) {
+			count++
+
+			if count == b.N {
+				close(done)
+				ctx.Quit()
+			}
+		},
+	}, options...)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		send(p, localMessage{message: EmptyMessage{}})
+	}
+
+	<-done
+	b.StopTimer()
+
+	Run()
+}
+
+func sendRoot() func(to *Pid, m localMessage) {
+	rootCtx := RootContext()
+
+	return func(to *Pid, m localMessage) {
+		rootCtx.Send(to, m.message)
+	}
+}
+
+//sendGoroutine delivers like Send used to (by handing every
+//message to a goroutine and waiting for it)
+func sendGoroutine(to *Pid, m localMessage) {
+	returnChan := make(chan error)
+
+	go func() {
+		returnChan <- to.mailbox.Push(m)
+	}()
+
+	<-returnChan
+}
+
+func BenchmarkContext_Send(b *testing.B) {
+	benchmarkSend(b, sendRoot())
+}
+
+func BenchmarkContext_SendGoroutine(b *testing.B) {
+	benchmarkSend(b, sendGoroutine)
+}
+
+func BenchmarkContext_SendLockFree(b *testing.B) {
+	benchmarkSend(b, sendRoot(), WithLockFreeMailbox())
+}
+
+func BenchmarkContext_SendGoroutineLockFree(b *testing.B) {
+	benchmarkSend(b, sendGoroutine, WithLockFreeMailbox())
+}
+
 func TestNewSystem(t *testing.T) {
 	_, err := NewSystem("test")
 
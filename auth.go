@@ -0,0 +1,187 @@
+package quacktors
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"github.com/Azer0s/qpmd"
+	"go.uber.org/atomic"
+	"net"
+	"time"
+)
+
+/*
+If a cookie is configured, machines authenticate each other while saying
+hello (on both gateways). The client sends a random challenge with its
+hello, the server answers with a challenge of its own and proves that it
+knows the cookie by signing the challenge of the client (HMAC). The client
+then does the same for the challenge of the server. The cookie itself never
+goes over the wire and both sides sign differently, so a signature of one
+side can't be replayed as the signature of the other side.
+*/
+
+const challengeSize = 32
+
+//handshakeTimeout is how long a remote machine has to say hello (and
+//authenticate) before the gateway drops the connection
+var handshakeTimeout = atomic.NewDuration(10 * time.Second)
+
+//ErrAuthenticationFailed is returned if a remote machine
+//doesn't know the cookie (see config.SetCookie).
+var ErrAuthenticationFailed = errors.New("authentication with remote machine failed")
+
+var errUnexpectedRequest = errors.New("expected hello request")
+
+func newChallenge() []byte {
+	challenge := make([]byte, challengeSize)
+
+	_, err := rand.Read(challenge)
+	try(err)
+
+	return challenge
+}
+
+func sign(role string, challenge []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(cookie))
+	mac.Write([]byte(role))
+	mac.Write(challenge)
+
+	return mac.Sum(nil)
+}
+
+func verify(role string, challenge []byte, digest interface{}) bool {
+	d, ok := digest.([]byte)
+
+	return ok && hmac.Equal(d, sign(role, challenge))
+}
+
+//clientHandshake says hello to a gateway of a remote machine
+//and authenticates the connection if a cookie is configured
+func clientHandshake(conn net.Conn, data map[string]interface{}) error {
+	var challenge []byte
+
+	if cookie != "" {
+		challenge = newChallenge()
+		data[challengeVal] = challenge
+	}
+
+	err := sendRequest(conn, qpmd.Request{
+		RequestType: qpmd.REQUEST_HELLO,
+		Data:        data,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	res, err := readResponse(conn)
+
+	if err != nil {
+		return err
+	}
+
+	if res.ResponseType != qpmd.RESPONSE_OK {
+		return errors.New("remote machine returned non okay result")
+	}
+
+	if cookie == "" {
+		return nil
+	}
+
+	serverChallenge, ok := res.Data[challengeVal].([]byte)
+
+	if !ok || !verify("server", challenge, res.Data[digestVal]) {
+		return ErrAuthenticationFailed
+	}
+
+	err = sendRequest(conn, qpmd.Request{
+		RequestType: authMessageType,
+		Data: map[string]interface{}{
+			digestVal: sign("client", serverChallenge),
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	res, err = readResponse(conn)
+
+	if err != nil {
+		return err
+	}
+
+	if res.ResponseType != qpmd.RESPONSE_OK {
+		return ErrAuthenticationFailed
+	}
+
+	return nil
+}
+
+//serverHandshake handles the hello of a remote machine on one of
+//the gateways and authenticates the connection if a cookie is configured
+func serverHandshake(conn net.Conn) (qpmd.Request, error) {
+	//an unauthenticated remote machine can't hold on to a gateway goroutine forever
+	err := conn.SetDeadline(time.Now().Add(handshakeTimeout.Load()))
+
+	if err != nil {
+		return qpmd.Request{}, err
+	}
+
+	req, err := handleHello(conn)
+
+	if err != nil {
+		return qpmd.Request{}, err
+	}
+
+	return req, conn.SetDeadline(time.Time{})
+}
+
+func handleHello(conn net.Conn) (qpmd.Request, error) {
+	req, err := readRequest(conn)
+
+	if err != nil {
+		return qpmd.Request{}, err
+	}
+
+	if req.RequestType != qpmd.REQUEST_HELLO {
+		_ = writeError(conn, errUnexpectedRequest)
+		return qpmd.Request{}, errUnexpectedRequest
+	}
+
+	if cookie == "" {
+		return req, writeOk(conn, make(map[string]interface{}))
+	}
+
+	clientChallenge, ok := req.Data[challengeVal].([]byte)
+
+	if !ok {
+		_ = writeError(conn, ErrAuthenticationFailed)
+		return qpmd.Request{}, ErrAuthenticationFailed
+	}
+
+	challenge := newChallenge()
+
+	err = writeOk(conn, map[string]interface{}{
+		challengeVal: challenge,
+		digestVal:    sign("server", clientChallenge),
+	})
+
+	if err != nil {
+		return qpmd.Request{}, err
+	}
+
+	auth, err := readRequest(conn)
+
+	if err != nil {
+		return qpmd.Request{}, err
+	}
+
+	if auth.RequestType != authMessageType || !verify("client", challenge, auth.Data[digestVal]) {
+		_ = writeError(conn, ErrAuthenticationFailed)
+		return qpmd.Request{}, ErrAuthenticationFailed
+	}
+
+	return req, writeOk(conn, make(map[string]interface{}))
+}
@@ -0,0 +1,222 @@
+package quacktors
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"github.com/Azer0s/qpmd"
+	"github.com/Azer0s/quacktors/mailbox"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+func withCookie(c string) func() {
+	old := cookie
+	cookie = c
+
+	return func() {
+		cookie = old
+	}
+}
+
+func TestCookieAuthentication(t *testing.T) {
+	rootCtx := RootContext()
+
+	defer withCookie("secret")()
+
+	received := make(chan Message, 1)
+
+	p := Spawn(func(ctx *Context, message Message) {
+		received <- message
+	})
+
+	m := &Machine{
+		MachineId:          "loopback",
+		Address:            "127.0.0.1",
+		MessageGatewayPort: messageGatewayPort,
+	}
+
+	mb := mailbox.New()
+	gatewayQuitChan := make(chan bool, 1)
+	okChan := make(chan bool, 1)
+	errorChan := make(chan error, 1)
+
+	go m.startMessageClient(mb, gatewayQuitChan, okChan, errorChan)
+
+	select {
+	case <-okChan:
+	case err := <-errorChan:
+		t.Fatal(err)
+	}
+
+	mb.In() <- remoteMessageTuple{To: p, Message: GenericMessage{Value: "authenticated"}}
+
+	select {
+	case m := <-received:
+		assert.Equal(t, GenericMessage{Value: "authenticated"}, m)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message wasn't received")
+	}
+
+	gatewayQuitChan <- true
+	mb.Close()
+
+	rootCtx.Kill(p)
+
+	Run()
+}
+
+func TestCookieAuthenticationRejected(t *testing.T) {
+	RootContext()
+
+	defer withCookie("secret")()
+
+	handshake := func(client func(conn net.Conn)) error {
+		clientConn, serverConn := net.Pipe()
+		defer clientConn.Close()
+		defer serverConn.Close()
+
+		errChan := make(chan error, 1)
+
+		go func() {
+			_, err := serverHandshake(serverConn)
+			errChan <- err
+		}()
+
+		client(clientConn)
+
+		return <-errChan
+	}
+
+	//a client without a challenge (i.e. without a cookie)
+	err := handshake(func(conn net.Conn) {
+		_ = sendRequest(conn, qpmd.Request{
+			RequestType: qpmd.REQUEST_HELLO,
+			Data:        map[string]interface{}{},
+		})
+
+		res, err := readResponse(conn)
+		assert.NoError(t, err)
+		assert.Equal(t, qpmd.RESPONSE_ERROR, res.ResponseType)
+	})
+	assert.Equal(t, ErrAuthenticationFailed, err)
+
+	//a client with the wrong cookie
+	err = handshake(func(conn net.Conn) {
+		challenge := newChallenge()
+
+		_ = sendRequest(conn, qpmd.Request{
+			RequestType: qpmd.REQUEST_HELLO,
+			Data: map[string]interface{}{
+				challengeVal: challenge,
+			},
+		})
+
+		res, err := readResponse(conn)
+		assert.NoError(t, err)
+		assert.Equal(t, qpmd.RESPONSE_OK, res.ResponseType)
+		assert.True(t, verify("server", challenge, res.Data[digestVal]))
+
+		mac := hmac.New(sha256.New, []byte("wrong"))
+		mac.Write([]byte("client"))
+		mac.Write(res.Data[challengeVal].([]byte))
+
+		_ = sendRequest(conn, qpmd.Request{
+			RequestType: authMessageType,
+			Data: map[string]interface{}{
+				digestVal: mac.Sum(nil),
+			},
+		})
+
+		res, err = readResponse(conn)
+		assert.NoError(t, err)
+		assert.Equal(t, qpmd.RESPONSE_ERROR, res.ResponseType)
+	})
+	assert.Equal(t, ErrAuthenticationFailed, err)
+
+	//the signature of the server can't be replayed by a client
+	err = handshake(func(conn net.Conn) {
+		_ = sendRequest(conn, qpmd.Request{
+			RequestType: qpmd.REQUEST_HELLO,
+			Data: map[string]interface{}{
+				challengeVal: newChallenge(),
+			},
+		})
+
+		res, err := readResponse(conn)
+		assert.NoError(t, err)
+
+		_ = sendRequest(conn, qpmd.Request{
+			RequestType: authMessageType,
+			Data: map[string]interface{}{
+				digestVal: sign("server", res.Data[challengeVal].([]byte)),
+			},
+		})
+
+		res, err = readResponse(conn)
+		assert.NoError(t, err)
+		assert.Equal(t, qpmd.RESPONSE_ERROR, res.ResponseType)
+	})
+	assert.Equal(t, ErrAuthenticationFailed, err)
+}
+
+func TestCookieAuthenticationServerRejected(t *testing.T) {
+	RootContext()
+
+	defer withCookie("secret")()
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	//a server that doesn't know the cookie
+	go func() {
+		_, _ = readRequest(serverConn)
+		_ = writeOk(serverConn, map[string]interface{}{
+			challengeVal: newChallenge(),
+			digestVal:    []byte("forged"),
+		})
+	}()
+
+	err := clientHandshake(clientConn, map[string]interface{}{})
+	assert.Equal(t, ErrAuthenticationFailed, err)
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	RootContext()
+
+	defer withCookie("secret")()
+
+	old := handshakeTimeout.Swap(50 * time.Millisecond)
+	defer handshakeTimeout.Store(old)
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	errChan := make(chan error, 1)
+
+	go func() {
+		_, err := serverHandshake(serverConn)
+		errChan <- err
+	}()
+
+	//a client that says hello but never authenticates
+	_ = sendRequest(clientConn, qpmd.Request{
+		RequestType: qpmd.REQUEST_HELLO,
+		Data: map[string]interface{}{
+			challengeVal: newChallenge(),
+		},
+	})
+
+	_, err := readResponse(clientConn)
+	assert.NoError(t, err)
+
+	select {
+	case err := <-errChan:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("handshake didn't time out")
+	}
+}
@@ -21,19 +21,13 @@ func (d *dynamicSupervisorComponent) Init(ctx *quacktors.Context) {
 	d.actorPids = make([]*quacktors.Pid, 0)
 
 	for _, tuple := range d.mapping {
-		relayPid := quacktors.SpawnStateful(Relay(tuple.name))
+		//the relays and the supervisor are children of the dynamic supervisor
+		//so they go down together with it
+		relayPid := ctx.SpawnStateful(Relay(tuple.name))
 		d.actorPids = append(d.actorPids, relayPid)
-
-		ctx.Defer(func() {
-			ctx.Kill(relayPid)
-		})
 	}
 
-	d.supervisorPid = quacktors.SpawnStateful(d.supervisor)
-
-	ctx.Defer(func() {
-		ctx.Kill(d.supervisorPid)
-	})
+	d.supervisorPid = ctx.SpawnStateful(d.supervisor)
 
 	ctx.Monitor(d.supervisorPid)
 }
@@ -166,8 +166,9 @@ func Call(context quacktors.Context, pid *quacktors.Pid, message quacktors.Messa
 //return a result within the timeout period,
 //an error is returned.
 func CallWithTimeout(context quacktors.Context, pid *quacktors.Pid, message quacktors.Message, duration time.Duration) (ResponseMessage, error) {
-	returnChan := make(chan ResponseMessage)
-	errChan := make(chan bool)
+	//buffered because nobody listens anymore after a timeout
+	returnChan := make(chan ResponseMessage, 1)
+	errChan := make(chan bool, 1)
 
 	p := quacktors.SpawnWithInit(func(ctx *quacktors.Context) {
 		ctx.Monitor(pid)
@@ -243,8 +244,9 @@ func Cast(context quacktors.Context, pid *quacktors.Pid, message quacktors.Messa
 //return a result within the timeout period,
 //an error is returned.
 func CastWithTimeout(context quacktors.Context, pid *quacktors.Pid, message quacktors.Message, duration time.Duration) (ReceivedMessage, error) {
-	returnChan := make(chan ReceivedMessage)
-	errChan := make(chan bool)
+	//buffered because nobody listens anymore after a timeout
+	returnChan := make(chan ReceivedMessage, 1)
+	errChan := make(chan bool, 1)
 
 	p := quacktors.SpawnWithInit(func(ctx *quacktors.Context) {
 		ctx.Monitor(pid)
@@ -89,6 +89,26 @@ func TestGenServerCastWithTimeout(t *testing.T) {
 	quacktors.Run()
 }
 
+func TestGenServerTimeoutThenDown(t *testing.T) {
+	context := quacktors.RootContext()
+
+	//the helper actor of a timed out call might still get the down
+	//message of the GenServer before it is killed (nobody reads its
+	//result anymore then, but it still has to go down)
+	for i := 0; i < 100; i++ {
+		pid := quacktors.Spawn(func(ctx *quacktors.Context, message quacktors.Message) {})
+
+		_, err := CallWithTimeout(context, pid, quacktors.EmptyMessage{}, 1*time.Millisecond)
+		assert.Error(t, err)
+
+		_, err = CastWithTimeout(context, pid, quacktors.EmptyMessage{}, 1*time.Millisecond)
+		assert.Error(t, err)
+
+		context.Kill(pid)
+		quacktors.Run()
+	}
+}
+
 func TestDeadGenServerCast(t *testing.T) {
 	genServerPid := quacktors.SpawnStateful(New(testGenServer{}))
 	context := quacktors.RootContext()
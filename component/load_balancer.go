@@ -18,15 +18,9 @@ type loadBalancerComponent struct {
 }
 
 func (l *loadBalancerComponent) Init(ctx *quacktors.Context) {
+	//the actors in the pool are children of the load balancer,
+	//so if the load balancer is killed forcefully, they go down too
 	l.spawnOrDestroy(ctx)
-
-	//if the load balancer is killed forcefully, kill all the
-	//spawned actors
-	ctx.Defer(func() {
-		for _, p := range l.pids {
-			ctx.Kill(p.pid)
-		}
-	})
 }
 
 func (l *loadBalancerComponent) spawnOrDestroy(ctx *quacktors.Context) {
@@ -40,7 +34,7 @@ func (l *loadBalancerComponent) spawnOrDestroy(ctx *quacktors.Context) {
 
 		for i := 0; i < delta; i++ {
 			p := &pidWithUsage{
-				pid:   quacktors.SpawnStateful(l.actor),
+				pid:   ctx.SpawnStateful(l.actor),
 				usage: 0,
 			}
 
@@ -2,6 +2,7 @@ package config
 
 import (
 	"github.com/Azer0s/quacktors/logging"
+	"time"
 )
 
 //SetLogger sets the Logger implementation used by quacktors.
@@ -25,3 +26,102 @@ func SetQpmdPort(port uint16) {
 func GetQpmdPort() uint16 {
 	return qpmdPort
 }
+
+//SetMaxFrameSize sets the maximum size (in bytes) of a single
+//frame (i.e. an encoded message or request) quacktors sends to
+//or accepts from remote machines. Bigger messages are dropped.
+//(16 MiB by default)
+func SetMaxFrameSize(size uint32) {
+	maxFrameSize = size
+}
+
+//GetMaxFrameSize gets the configured maximum frame size.
+func GetMaxFrameSize() uint32 {
+	return maxFrameSize
+}
+
+//SetChildShutdownTimeout sets how long an actor that goes
+//down waits for each of its children to go down. Children that
+//take longer are reported and abandoned so they can't block
+//the cleanup of their parent. (5 seconds by default)
+func SetChildShutdownTimeout(timeout time.Duration) {
+	childShutdownTimeout = timeout
+}
+
+//GetChildShutdownTimeout gets the configured child shutdown timeout.
+func GetChildShutdownTimeout() time.Duration {
+	return childShutdownTimeout
+}
+
+//TLSConfig configures TLS for the connections between machines
+//(both gateways and the system server lookup connection).
+type TLSConfig struct {
+	//CertFile and KeyFile are the paths to the PEM encoded
+	//certificate and private key of the machine. The certificate
+	//is used by the gateways and as client certificate.
+	CertFile string
+	KeyFile  string
+	//CAFile is the path to the PEM encoded CA certificates
+	//remote machines are verified against. If it is empty,
+	//the system certificate pool is used.
+	CAFile string
+	//VerifyClient makes the gateways (and system servers)
+	//require and verify client certificates (mutual TLS).
+	VerifyClient bool
+}
+
+//SetTLSConfig enables TLS for all connections between machines.
+//Every machine in the cluster needs to have TLS enabled. A nil
+//config disables TLS. (TLS is disabled by default)
+func SetTLSConfig(config *TLSConfig) {
+	tlsConfig = config
+}
+
+//GetTLSConfig gets the configured TLSConfig (nil if TLS is disabled).
+func GetTLSConfig() *TLSConfig {
+	return tlsConfig
+}
+
+//SetCookie sets the shared secret machines authenticate each
+//other with when they connect. Only machines with the same
+//cookie can connect to each other. An empty cookie disables
+//authentication. (authentication is disabled by default)
+func SetCookie(c string) {
+	cookie = c
+}
+
+//GetCookie gets the configured cookie.
+func GetCookie() string {
+	return cookie
+}
+
+//ReconnectPolicy configures how quacktors reconnects to a remote
+//machine after the connection dropped. The delay between attempts
+//starts at InitialBackoff and doubles with every attempt (up to
+//MaxBackoff).
+type ReconnectPolicy struct {
+	//InitialBackoff is the delay before the first attempt.
+	InitialBackoff time.Duration
+	//MaxBackoff caps the delay between two attempts.
+	MaxBackoff time.Duration
+	//MaxAttempts is the number of attempts after which the
+	//machine is given up on (0 means there is no limit).
+	MaxAttempts int
+	//Jitter is the fraction (between 0 and 1) of every delay
+	//that is randomized so that machines don't all reconnect
+	//at the same time.
+	Jitter float64
+}
+
+//SetReconnectPolicy makes quacktors reconnect to remote machines
+//whose connection dropped. Until the machine is given up on,
+//messages to it are queued and monitors stay in place. A nil
+//policy disables reconnecting. (reconnecting is disabled by default)
+func SetReconnectPolicy(policy *ReconnectPolicy) {
+	reconnectPolicy = policy
+}
+
+//GetReconnectPolicy gets the configured ReconnectPolicy (nil if reconnecting is disabled).
+func GetReconnectPolicy() *ReconnectPolicy {
+	return reconnectPolicy
+}
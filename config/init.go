@@ -2,13 +2,21 @@ package config
 
 import (
 	"github.com/Azer0s/quacktors/logging"
+	"time"
 )
 
 var logger logging.Logger
 var qpmdPort uint16
+var maxFrameSize uint32
+var childShutdownTimeout time.Duration
+var tlsConfig *TLSConfig
+var cookie string
+var reconnectPolicy *ReconnectPolicy
 
 func init() {
 	logger = &logging.LogrusLogger{}
 	logger.Init()
 	qpmdPort = 7161
+	maxFrameSize = 16 * 1024 * 1024
+	childShutdownTimeout = 5 * time.Second
 }
@@ -1,12 +1,25 @@
 package quacktors
 
 import (
+	"errors"
+	"github.com/Azer0s/quacktors/mailbox"
+	"github.com/Azer0s/quacktors/metrics"
 	"github.com/opentracing/opentracing-go"
 	"reflect"
 	"sync"
 	"time"
 )
 
+//ErrReceiveTimeout is returned by Context.Receive if no
+//matching Message arrived within the timeout period.
+var ErrReceiveTimeout = errors.New("receive timed out")
+
+//ErrStashFull is returned by Context.Stash if the stash
+//of the actor has reached its capacity.
+var ErrStashFull = errors.New("stash is full")
+
+const defaultStashCapacity = 1000
+
 //The Context struct defines the actor context and
 //provides ways for an actor to interact with the
 //rest of the system. Actors are provided a
@@ -24,6 +37,22 @@ type Context struct {
 	Logger                contextLogger
 	deferred              []func()
 	passthroughPoisonPill bool
+	mailbox               *mailbox.Mailbox
+	quitChan              <-chan bool
+	monitorChan           <-chan *Pid
+	demonitorChan         <-chan *Pid
+	linkChan              <-chan *Pid
+	unlinkChan            <-chan *Pid
+	exitChan              <-chan ExitMessage
+	trapExits             bool
+	children              []*Pid
+	receiveTimeout        time.Duration
+	behaviors             []func(ctx *Context, message Message)
+	childrenPruneAt       int
+	current               *localMessage
+	stashed               bool
+	stash                 []interface{}
+	stashCapacity         int
 }
 
 //PassthroughPoisonPill enables message passthrough for
@@ -76,9 +105,20 @@ func (c *Context) Self() *Pid {
 
 //Send sends a Message to another actor by its PID.
 func (c *Context) Send(to *Pid, message Message) {
-	t := reflect.ValueOf(message).Type().Kind()
+	_ = c.TrySend(to, message)
+}
 
-	if t == reflect.Ptr {
+//TrySend is the same as Send but returns an error if the
+//Message was rejected by the receiving actor. This is the
+//case if the receiver is a local actor with a bounded
+//mailbox that uses the mailbox.FAIL_POLICY and is full
+//(TrySend then returns mailbox.ErrFull).
+func (c *Context) TrySend(to *Pid, message Message) error {
+	return c.send(to, message, nil)
+}
+
+func (c *Context) send(to *Pid, message Message, replyTo *Pid) error {
+	if reflect.TypeOf(message).Kind() == reflect.Ptr {
 		panic("Send cannot be called with a pointer to a Message")
 	}
 
@@ -90,15 +130,293 @@ func (c *Context) Send(to *Pid, message Message) {
 		spanContext = c.span.Context()
 	}
 
-	doSend(to, message, spanContext)
+	var sender *Pid
+	if c.mailbox != nil {
+		//only actors can be replied to (i.e. not a RootContext)
+		sender = c.self
+		sender.sent.Inc()
+	}
+
+	return deliver(to, localMessage{
+		message:     message,
+		spanContext: spanContext,
+		sender:      sender,
+		replyTo:     replyTo,
+	})
+}
+
+//Sender returns the PID of the actor that sent the Message
+//that is currently being processed. Sender returns nil if
+//the Message wasn't sent by an actor (e.g. if it was sent
+//with a RootContext or if it is a DownMessage). Sender can
+//only be called from within Run.
+func (c *Context) Sender() *Pid {
+	if c.current == nil {
+		return nil
+	}
+
+	return c.current.sender
+}
+
+//Ask sends a Message to another actor by its PID and returns
+//a Future that holds the reply of the actor (see Reply). If
+//the actor doesn't reply within the timeout or goes down
+//before it replies, the Future fails. A timeout of 0 or less
+//waits indefinitely (the Future still fails if the actor goes
+//down). Ask works with both local and remote actors.
+func (c *Context) Ask(to *Pid, message Message, timeout time.Duration) *Future {
+	return startAsk(c, to, message, timeout)
+}
+
+//Reply sends a Message back to the actor that asked the
+//calling actor (see Ask) with the Message that is currently
+//being processed. If the current Message wasn't sent via
+//Ask, the reply is sent to the Sender instead. Reply returns
+//false if there is no one to reply to. Reply can only be
+//called from within Run.
+func (c *Context) Reply(message Message) bool {
+	if c.current == nil {
+		return false
+	}
+
+	if c.current.replyTo != nil {
+		c.Send(c.current.replyTo, message)
+		return true
+	}
+
+	if c.current.sender != nil {
+		c.Send(c.current.sender, message)
+		return true
+	}
+
+	return false
+}
+
+//Receive scans the mailbox of the calling actor for the first
+//Message the Matcher matches and returns it. All other messages
+//stay in the mailbox (in the order they were received in) and
+//are passed on to Run as usual. If no matching Message arrives
+//within the timeout, Receive returns ErrReceiveTimeout. A
+//timeout of 0 or less waits indefinitely. Receive can only be
+//called from within an actor (i.e. not with a RootContext).
+func (c *Context) Receive(matcher Matcher, timeout time.Duration) (Message, error) {
+	if c.mailbox == nil {
+		panic("Receive can only be called from within an actor")
+	}
+
+	if c.self.dispatched {
+		return c.receiveDispatched(matcher, timeout)
+	}
+
+	skipped := make([]interface{}, 0)
+
+	defer func() {
+		//put everything we didn't want back to where it was
+		if len(skipped) != 0 {
+			c.mailbox.PushFront(skipped...)
+		}
+	}()
+
+	var timeoutChan <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		timeoutChan = timer.C
+	}
+
+	messageChan := c.mailbox.Out()
+
+	//lock-free mailboxes don't have an output channel, the actor parks on them instead
+	getWaitChan := func() <-chan bool {
+		if messageChan != nil {
+			return nil
+		}
+
+		return c.mailbox.Wait()
+	}
+
+	//match returns true if the message matches and puts it aside otherwise
+	match := func(m localMessage) bool {
+		if matcher(m.message) {
+			if m.stale() {
+				//the timer of the message was canceled in the meantime
+				return false
+			}
+
+			metrics.RecordReceive(c.self.Id)
+			return true
+		}
+
+		skipped = append(skipped, m)
+		return false
+	}
+
+	for {
+		select {
+		case <-c.quitChan:
+			logger.Info("actor received quit event while receiving",
+				"pid", c.self.Id)
+			panic(quitAction{reason: ExitReason{Kind: KILLED_EXIT}})
+		case mi := <-messageChan:
+			if m := mi.(localMessage); match(m) {
+				return m.message, nil
+			}
+		case <-getWaitChan():
+			if mi, ok := c.mailbox.Pop(); ok && match(mi.(localMessage)) {
+				return mi.(localMessage).message, nil
+			}
+		case monitor := <-c.monitorChan:
+			handleMonitorRequest(c.self, monitor)
+		case monitor := <-c.demonitorChan:
+			handleDemonitorRequest(c.self, monitor)
+		case from := <-c.linkChan:
+			handleLinkRequest(c.self, from)
+		case from := <-c.unlinkChan:
+			handleUnlinkRequest(c.self, from)
+		case e := <-c.exitChan:
+			if !c.handleExitSignal(e) {
+				continue
+			}
+
+			if matcher(e) {
+				return e, nil
+			}
+
+			skipped = append(skipped, localMessage{message: e})
+		case <-timeoutChan:
+			return nil, ErrReceiveTimeout
+		}
+	}
+}
+
+//Stash stashes the Message that is currently being processed
+//so it can be replayed later on by calling UnstashAll. Stash
+//returns ErrStashFull if the stash has reached its capacity
+//(see SetStashCapacity). Messages that are still stashed when
+//the actor goes down are recorded as dropped. Stash can only
+//be called from within Run.
+func (c *Context) Stash() error {
+	if c.mailbox == nil {
+		panic("Stash can only be called from within an actor")
+	}
+
+	if c.current == nil {
+		return errors.New("there is no message to stash")
+	}
+
+	//stashing the same message twice would replay it twice
+	if c.stashed {
+		return errors.New("the current message was already stashed")
+	}
+
+	if len(c.stash) >= c.stashCapacity {
+		return ErrStashFull
+	}
+
+	c.stash = append(c.stash, *c.current)
+	c.stashed = true
+
+	return nil
+}
+
+//UnstashAll puts all stashed messages back to the front of
+//the mailbox of the calling actor. The messages keep the
+//order they were stashed in and are processed before any
+//other message in the mailbox.
+func (c *Context) UnstashAll() {
+	if c.mailbox == nil {
+		panic("UnstashAll can only be called from within an actor")
+	}
+
+	if len(c.stash) == 0 {
+		return
+	}
+
+	c.mailbox.PushFront(c.stash...)
+	c.stash = make([]interface{}, 0)
+}
+
+//SetStashCapacity sets the maximum amount of messages
+//the calling actor can stash. (1000 by default)
+func (c *Context) SetStashCapacity(capacity int) {
+	c.stashCapacity = capacity
+}
+
+//SetReceiveTimeout makes the actor receive a ReceiveTimeoutMessage
+//if it hasn't received any Message within the duration. The
+//timeout is restarted whenever a Message arrives (including the
+//ReceiveTimeoutMessage itself, so the actor receives it repeatedly
+//while it is idle). A duration of 0 or less turns the receive
+//timeout off.
+func (c *Context) SetReceiveTimeout(duration time.Duration) {
+	c.receiveTimeout = duration
+}
+
+//Become replaces the current behavior of the calling actor
+//(i.e. the function that handles incoming messages instead
+//of Actor.Run) with a new one. If behaviors were stacked
+//(see BecomeStacked), only the topmost one is replaced.
+func (c *Context) Become(behavior func(ctx *Context, message Message)) {
+	if len(c.behaviors) == 0 {
+		c.behaviors = append(c.behaviors, behavior)
+		return
+	}
+
+	c.behaviors[len(c.behaviors)-1] = behavior
+}
+
+//BecomeStacked pushes a new behavior on top of the current
+//one so the actor can return to the current behavior by
+//calling Unbecome.
+func (c *Context) BecomeStacked(behavior func(ctx *Context, message Message)) {
+	c.behaviors = append(c.behaviors, behavior)
+}
+
+//Unbecome pops the current behavior off the behavior stack
+//and returns to the previous one. If there is no previous
+//behavior, the actor returns to Actor.Run.
+func (c *Context) Unbecome() {
+	if len(c.behaviors) == 0 {
+		return
+	}
+
+	c.behaviors[len(c.behaviors)-1] = nil
+	c.behaviors = c.behaviors[:len(c.behaviors)-1]
+}
+
+//UnbecomeAll drops all behaviors so the actor returns to
+//Actor.Run.
+func (c *Context) UnbecomeAll() {
+	c.behaviors = nil
+}
+
+func (c *Context) receive(actor Actor, message Message) {
+	if len(c.behaviors) == 0 {
+		actor.Run(c, message)
+		return
+	}
+
+	c.behaviors[len(c.behaviors)-1](c, message)
 }
 
 //SendAfter schedules a Message to be sent to another
 //actor by its PID after a timer has finished. SendAfter
 //also returns an Abortable so the scheduled Send can
 //be stopped. If the sending actor goes down before the
-//timer has completed, the Send operation is still executed.
+//timer has completed, the Message isn't sent.
 func (c *Context) SendAfter(to *Pid, message Message, duration time.Duration) Abortable {
+	if c.mailbox != nil {
+		key := "after_" + uuidString()
+		c.self.startTimer(key, to, message, duration, false)
+
+		return &timerAbortable{
+			pid: c.self,
+			key: key,
+		}
+	}
+
+	//a RootContext has no actor the timer could be stopped with
 	quitChan := make(chan bool)
 
 	go func() {
@@ -116,6 +434,176 @@ func (c *Context) SendAfter(to *Pid, message Message, duration time.Duration) Ab
 	return &sendAfterAbortable{quitChan: quitChan}
 }
 
+//SendInterval sends a Message to another actor by its PID
+//every time the interval has passed. SendInterval also
+//returns an Abortable so the interval can be stopped. The
+//interval is stopped automatically as soon as the sending
+//actor goes down. SendInterval can
+//only be called from within an actor (i.e. not with a
+//RootContext).
+func (c *Context) SendInterval(to *Pid, message Message, interval time.Duration) Abortable {
+	if c.mailbox == nil {
+		panic("SendInterval can only be called from within an actor")
+	}
+
+	key := "interval_" + uuidString()
+	c.self.startTimer(key, to, message, interval, true)
+
+	return &timerAbortable{
+		pid: c.self,
+		key: key,
+	}
+}
+
+//StartTimer sends a Message to the calling actor after the
+//duration has passed. If there already is a timer with the
+//same key, it is replaced (i.e. the old timer won't fire).
+//Timers can be canceled with CancelTimer and are canceled
+//automatically as soon as the actor goes down. StartTimer
+//can only be called from within an actor (i.e. not with a
+//RootContext).
+func (c *Context) StartTimer(key string, message Message, duration time.Duration) {
+	if c.mailbox == nil {
+		panic("StartTimer can only be called from within an actor")
+	}
+
+	c.self.startTimer(key, c.self, message, duration, false)
+}
+
+//StartPeriodicTimer is the same as StartTimer but sends the
+//Message every time the interval has passed until the timer
+//is canceled.
+func (c *Context) StartPeriodicTimer(key string, message Message, interval time.Duration) {
+	if c.mailbox == nil {
+		panic("StartPeriodicTimer can only be called from within an actor")
+	}
+
+	c.self.startTimer(key, c.self, message, interval, true)
+}
+
+//CancelTimer cancels the timer with the provided key. If
+//there is no such timer, CancelTimer does nothing.
+func (c *Context) CancelTimer(key string) {
+	if c.mailbox == nil {
+		panic("CancelTimer can only be called from within an actor")
+	}
+
+	c.self.cancelTimer(key)
+}
+
+//Register registers the calling actor under a name so
+//other actors can look it up (see Whereis) or send messages
+//to it by name (see SendNamed). An actor can be registered
+//under multiple names. Names are removed automatically as
+//soon as the actor goes down. If another actor is already
+//registered under the name, Register returns ErrNameTaken.
+//Register can only be called from within an actor (i.e.
+//not with a RootContext).
+func (c *Context) Register(name string) error {
+	if c.mailbox == nil {
+		panic("Register can only be called from within an actor")
+	}
+
+	return registerName(name, c.self)
+}
+
+//Unregister removes a name the calling actor is registered
+//under. If the name belongs to another actor, Unregister
+//does nothing.
+func (c *Context) Unregister(name string) {
+	unregisterName(name, c.self)
+}
+
+//RegisterGlobal registers the calling actor under a global
+//name that is visible on every connected machine (see
+//WhereisGlobal and SendGlobal). The registration is propagated
+//over the general purpose gateway. Global names are removed as
+//soon as the actor goes down or the connection to its machine
+//goes down. If the name is already taken, RegisterGlobal returns
+//ErrNameTaken. If two machines register the same name at the
+//same time, the conflict is resolved by the ConflictPolicy (see
+//SetGlobalConflictPolicy). RegisterGlobal can only be called
+//from within an actor (i.e. not with a RootContext).
+func (c *Context) RegisterGlobal(name string) error {
+	if c.mailbox == nil {
+		panic("RegisterGlobal can only be called from within an actor")
+	}
+
+	return registerGlobalName(name, c.self)
+}
+
+//UnregisterGlobal removes a global name the calling actor is
+//registered under. If the name belongs to another actor,
+//UnregisterGlobal does nothing.
+func (c *Context) UnregisterGlobal(name string) {
+	unregisterGlobalName(name, c.self)
+}
+
+//SendGlobal sends a Message to the actor that is registered
+//under a global name, no matter which machine it is on. If
+//there is no such actor, SendGlobal returns ErrNameNotFound.
+func (c *Context) SendGlobal(name string, message Message) error {
+	pid, ok := WhereisGlobal(name)
+
+	if !ok {
+		return ErrNameNotFound
+	}
+
+	return c.TrySend(pid, message)
+}
+
+//Join adds the calling actor to a process group. Process
+//groups span all connected machines (see Members). An actor
+//leaves all of its groups as soon as it goes down. Join can
+//only be called from within an actor (i.e. not with a
+//RootContext).
+func (c *Context) Join(group string) {
+	if c.mailbox == nil {
+		panic("Join can only be called from within an actor")
+	}
+
+	joinGroup(group, c.self)
+}
+
+//Leave removes the calling actor from a process group.
+func (c *Context) Leave(group string) {
+	leaveGroup(group, c.self)
+}
+
+//Broadcast sends a Message to all members of a process
+//group, no matter which machine they are on.
+func (c *Context) Broadcast(group string, message Message) {
+	for _, pid := range Members(group) {
+		c.Send(pid, message)
+	}
+}
+
+//SendNamed sends a Message to the actor that is registered
+//under a name. If there is no such actor, SendNamed returns
+//ErrNameNotFound.
+func (c *Context) SendNamed(name string, message Message) error {
+	pid, ok := Whereis(name)
+
+	if !ok {
+		return ErrNameNotFound
+	}
+
+	return c.TrySend(pid, message)
+}
+
+//MonitorNamed starts a monitor on the actor that is currently
+//registered under a name (see Monitor). If there is no such
+//actor, MonitorNamed returns ErrNameNotFound.
+func (c *Context) MonitorNamed(name string) (Abortable, error) {
+	pid, ok := Whereis(name)
+
+	if !ok {
+		return &noopAbortable{}, ErrNameNotFound
+	}
+
+	return c.Monitor(pid), nil
+}
+
 //Kill kills another actor by its PID.
 func (c *Context) Kill(pid *Pid) {
 	go func() {
@@ -126,7 +614,7 @@ func (c *Context) Kill(pid *Pid) {
 
 			m, ok := getMachine(pid.MachineId)
 
-			if ok && m.connected {
+			if ok && m.isConnected() {
 				m.quitChan <- pid
 				return
 			}
@@ -144,12 +632,214 @@ func (c *Context) Kill(pid *Pid) {
 
 //Quit kills the calling actor.
 func (c *Context) Quit() {
-	panic(quitAction{})
+	panic(quitAction{reason: ExitReason{Kind: NORMAL_EXIT}})
+}
+
+//Spawn spawns an Actor from an anonymous receive function as
+//a child of the calling actor and returns the *Pid of the
+//Actor. As soon as the calling actor goes down, all of its
+//children go down as well (see Children). Spawn can only be
+//called from within an actor (i.e. not with a RootContext).
+func (c *Context) Spawn(action func(ctx *Context, message Message)) *Pid {
+	return c.SpawnWithOptions(action)
+}
+
+//SpawnWithOptions is the same as Spawn but with SpawnOptions.
+func (c *Context) SpawnWithOptions(action func(ctx *Context, message Message), options ...SpawnOption) *Pid {
+	return c.SpawnStatefulWithOptions(&StatelessActor{
+		InitFunction:    func(ctx *Context) {},
+		ReceiveFunction: action,
+	}, options...)
+}
+
+//SpawnWithInit is the same as Spawn but also takes an
+//anonymous init function.
+func (c *Context) SpawnWithInit(init func(ctx *Context), action func(ctx *Context, message Message)) *Pid {
+	return c.SpawnWithInitWithOptions(init, action)
+}
+
+//SpawnWithInitWithOptions is the same as SpawnWithInit but
+//with SpawnOptions.
+func (c *Context) SpawnWithInitWithOptions(init func(ctx *Context), action func(ctx *Context, message Message), options ...SpawnOption) *Pid {
+	return c.SpawnStatefulWithOptions(&StatelessActor{
+		InitFunction:    init,
+		ReceiveFunction: action,
+	}, options...)
+}
+
+//SpawnStateful spawns an Actor as a child of the calling
+//actor (see Spawn).
+func (c *Context) SpawnStateful(actor Actor) *Pid {
+	return c.SpawnStatefulWithOptions(actor)
+}
+
+//SpawnStatefulWithOptions spawns an Actor with the provided
+//SpawnOptions as a child of the calling actor (see Spawn).
+func (c *Context) SpawnStatefulWithOptions(actor Actor, options ...SpawnOption) *Pid {
+	if c.mailbox == nil {
+		panic("Spawn can only be called from within an actor")
+	}
+
+	pid := startActor(actor, append(options, withParent(c.self))...)
+
+	if len(c.children) >= c.childrenPruneAt {
+		//forget about children that went down in the meantime every now and then
+		//so actors that spawn a lot of short lived children don't leak memory
+		c.pruneChildren()
+		c.childrenPruneAt = 2*len(c.children) + 16
+	}
+
+	c.children = append(c.children, pid)
+
+	return pid
+}
+
+//Children returns the PIDs of all children of the calling
+//actor that are still alive in the order they were spawned in.
+//When an actor goes down, it stops its children in reverse
+//order (i.e. the child that was spawned last is stopped first)
+//and waits for each child (and in turn, its subtree) to go
+//down before stopping the next one.
+func (c *Context) Children() []*Pid {
+	c.pruneChildren()
+
+	children := make([]*Pid, len(c.children))
+	copy(children, c.children)
+
+	return children
+}
+
+func (c *Context) pruneChildren() {
+	alive := c.children[:0]
+
+	for _, child := range c.children {
+		if _, ok := getByPidId(child.Id); ok {
+			alive = append(alive, child)
+		}
+	}
+
+	//don't hold on to the dead children
+	for i := len(alive); i < len(c.children); i++ {
+		c.children[i] = nil
+	}
+
+	c.children = alive
+}
+
+func (c *Context) stopChildren() {
+	for i := len(c.children) - 1; i >= 0; i-- {
+		child := c.children[i]
+
+		if _, ok := getByPidId(child.Id); !ok {
+			continue
+		}
+
+		logger.Debug("stopping child actor",
+			"pid", c.self.Id,
+			"child_pid", child.Id)
+
+		//a child that is stuck in Run doesn't read its quit channel
+		go child.die()
+
+		select {
+		case <-child.done:
+		case <-time.After(childShutdownTimeout):
+			logger.Warn("child actor didn't go down in time, abandoning it",
+				"pid", c.self.Id,
+				"child_pid", child.Id,
+				"timeout", childShutdownTimeout.String())
+		}
+	}
+
+	c.children = make([]*Pid, 0)
+}
+
+//Link links the calling actor to another actor by its PID.
+//Links are bidirectional: as soon as one of the linked actors
+//goes down abnormally (i.e. not by quitting or by a PoisonPill),
+//the other one goes down with the same ExitReason. If the
+//linked actor is already dead or the connection to its machine
+//goes down, the calling actor goes down with NO_PROC_EXIT or
+//NO_CONNECTION_EXIT respectively. Actors that trap exits (see
+//TrapExits) receive an ExitMessage instead. Link can only be
+//called from within an actor (i.e. not with a RootContext).
+func (c *Context) Link(pid *Pid) {
+	if c.mailbox == nil {
+		panic("Link can only be called from within an actor")
+	}
+
+	if pid.Is(c.self) || c.self.isLinked(pid) {
+		return
+	}
+
+	logger.Info("setting up link",
+		"linked_gpid", pid.String(),
+		"link_pid", c.self.Id)
+
+	c.self.addLink(pid)
+	sendLinkRequest(c.self, pid)
+}
+
+//Unlink removes the link between the calling actor and
+//another actor by its PID. Unlink can only be called from
+//within an actor (i.e. not with a RootContext).
+func (c *Context) Unlink(pid *Pid) {
+	if c.mailbox == nil {
+		panic("Unlink can only be called from within an actor")
+	}
+
+	if !c.self.isLinked(pid) {
+		return
+	}
+
+	logger.Info("removing link",
+		"linked_gpid", pid.String(),
+		"link_pid", c.self.Id)
+
+	c.self.removeLink(pid)
+	sendUnlinkRequest(c.self, pid)
+}
+
+//TrapExits sets whether the calling actor traps exits. If set
+//to true, the actor doesn't go down when a linked actor goes
+//down but receives an ExitMessage instead.
+func (c *Context) TrapExits(val bool) {
+	c.trapExits = val
+}
+
+//handleExitSignal handles an exit signal from a linked actor and
+//returns true if the ExitMessage should be passed on to the actor.
+func (c *Context) handleExitSignal(e ExitMessage) bool {
+	if !c.self.isLinked(e.Who) {
+		//the link was removed in the meantime
+		return false
+	}
+
+	c.self.removeLink(e.Who)
+
+	logger.Info("actor received exit signal from linked actor",
+		"pid", c.self.Id,
+		"linked_gpid", e.Who.String(),
+		"reason", e.Reason.String())
+
+	if c.trapExits {
+		return true
+	}
+
+	if e.Reason.Abnormal() {
+		panic(quitAction{reason: e.Reason})
+	}
+
+	return false
 }
 
 //MonitorMachine starts a monitor on a connection to
 //a remote machine. As soon as the remote disconnects,
 //a DisconnectMessage is sent to the monitoring actor.
+//If a reconnect policy is configured, the monitoring
+//actor is sent a MachineReconnectedMessage whenever
+//the connection dropped and was re-established (and the
+//DisconnectMessage only once quacktors gives up).
 //MonitorMachine also returns an Abortable so the
 //monitor can be canceled (i.e. no DisconnectMessage
 //will be sent out if the monitored actor goes down).
@@ -161,7 +851,7 @@ func (c *Context) MonitorMachine(machine *Machine) Abortable {
 		"monitored_machine", machine.MachineId,
 		"monitor_pid", c.self.Id)
 
-	if !machine.connected {
+	if !machine.isConnected() {
 		//The remote machine already disconnected, send a down message immediately
 
 		logger.Warn("monitored machine already disconnected, sending out DisconnectMessage to monitor immediately",
@@ -193,6 +883,10 @@ func (c *Context) Monitor(pid *Pid) Abortable {
 		"monitored_gpid", pid.String(),
 		"monitor_pid", c.self.Id)
 
+	if c.mailbox != nil {
+		c.self.addMonitored(pid)
+	}
+
 	go func() {
 		if pid.MachineId != machineId {
 			logger.Debug("pid to monitor is not on this machine, forwarding to remote machine",
@@ -201,7 +895,7 @@ func (c *Context) Monitor(pid *Pid) Abortable {
 				"machine_id", pid.MachineId)
 
 			m, ok := getMachine(pid.MachineId)
-			if ok && m.connected {
+			if ok && m.isConnected() {
 				okChan <- true
 
 				m.monitorChan <- remoteMonitorTuple{From: c.self, To: pid}
@@ -225,6 +919,16 @@ func (c *Context) Monitor(pid *Pid) Abortable {
 			}
 		}()
 
+		if p := dispatchedPid(pid); p != nil {
+			if !p.pushSignal(monitorSignal{monitor: c.self}) {
+				errorChan <- true
+				return
+			}
+
+			okChan <- true
+			return
+		}
+
 		if pid.monitorChan == nil {
 			errorChan <- true
 			return
@@ -249,7 +953,12 @@ func (c *Context) Monitor(pid *Pid) Abortable {
 			"monitored_gpid", pid.String(),
 			"monitor_pid", c.self.Id)
 
-		doSend(c.self, DownMessage{Who: pid}, nil)
+		reason := ExitReason{Kind: NO_PROC_EXIT}
+		if pid.MachineId != machineId {
+			reason.Kind = NO_CONNECTION_EXIT
+		}
+
+		doSend(c.self, DownMessage{Who: pid, Reason: reason}, nil)
 		return &noopAbortable{}
 	}
 }
@@ -0,0 +1,372 @@
+package quacktors
+
+import (
+	"container/list"
+	"github.com/Azer0s/quacktors/metrics"
+	"go.uber.org/atomic"
+	"sync"
+	"time"
+)
+
+const defaultThroughput = 10
+
+//A Dispatcher runs actors on a bounded pool of worker goroutines
+//instead of giving every actor a goroutine of its own (see
+//WithDispatcher). Actors only occupy a worker while they have
+//messages to process, so a Dispatcher can run lots of mostly
+//idle actors. Every actor processes at most throughput messages
+//before it is put to the back of the run queue, so busy actors
+//can't starve the other actors on the same Dispatcher. Note that
+//an actor that blocks (e.g. on Context.Receive or a Future)
+//blocks a worker for that time.
+type Dispatcher struct {
+	name       string
+	throughput int
+	//queue is nil if the dispatcher is pinned (every actor gets its own queue)
+	queue  *runQueue
+	closed *atomic.Bool
+}
+
+//NewDispatcher creates a Dispatcher that runs its actors on a
+//dedicated pool of workers goroutines. A throughput of 0 or
+//less uses the default throughput (10 messages).
+func NewDispatcher(name string, workers int, throughput int) *Dispatcher {
+	if workers <= 0 {
+		panic("a dispatcher needs at least one worker")
+	}
+
+	d := &Dispatcher{
+		name:       name,
+		throughput: throughputOrDefault(throughput),
+		queue:      newRunQueue(),
+		closed:     atomic.NewBool(false),
+	}
+
+	logger.Info("starting dispatcher",
+		"dispatcher", name,
+		"workers", workers)
+
+	for i := 0; i < workers; i++ {
+		go d.queue.work()
+	}
+
+	return d
+}
+
+//NewPinnedDispatcher creates a Dispatcher that pins every actor
+//to a worker goroutine of its own. Pinned actors still don't need
+//a goroutine for their mailbox or their system requests (monitors,
+//links, etc). A throughput of 0 or less uses the default
+//throughput (10 messages).
+func NewPinnedDispatcher(name string, throughput int) *Dispatcher {
+	return &Dispatcher{
+		name:       name,
+		throughput: throughputOrDefault(throughput),
+		closed:     atomic.NewBool(false),
+	}
+}
+
+//Name returns the name of the Dispatcher.
+func (d *Dispatcher) Name() string {
+	return d.name
+}
+
+//Close stops the workers of the Dispatcher. Actors that still run
+//on the Dispatcher don't process any messages after that, so Close
+//should only be called once all of them went down. Spawning an
+//actor on a closed Dispatcher panics. Closing a pinned Dispatcher
+//only prevents new actors from being spawned on it (its workers
+//stop together with their actors anyway).
+func (d *Dispatcher) Close() {
+	if !d.closed.CAS(false, true) {
+		return
+	}
+
+	logger.Info("stopping dispatcher",
+		"dispatcher", d.name)
+
+	if d.queue != nil {
+		d.queue.close()
+	}
+}
+
+func throughputOrDefault(throughput int) int {
+	if throughput <= 0 {
+		return defaultThroughput
+	}
+
+	return throughput
+}
+
+//attach creates the scheduling state of an actor on the dispatcher
+func (d *Dispatcher) attach() *dispatchedActor {
+	if d.closed.Load() {
+		panic("can't spawn an actor on a closed dispatcher")
+	}
+
+	a := &dispatchedActor{
+		state:      atomic.NewInt32(actorIdle),
+		queue:      d.queue,
+		throughput: d.throughput,
+	}
+
+	if a.queue == nil {
+		a.queue = newRunQueue()
+		a.pinned = true
+		go a.queue.work()
+	}
+
+	return a
+}
+
+type runQueue struct {
+	mu     *sync.Mutex
+	cond   *sync.Cond
+	actors *list.List
+	closed bool
+}
+
+func newRunQueue() *runQueue {
+	mu := &sync.Mutex{}
+
+	return &runQueue{
+		mu:     mu,
+		cond:   sync.NewCond(mu),
+		actors: list.New(),
+	}
+}
+
+func (q *runQueue) push(a *dispatchedActor) {
+	q.mu.Lock()
+	q.actors.PushBack(a)
+	q.mu.Unlock()
+
+	q.cond.Signal()
+}
+
+func (q *runQueue) pop() (*dispatchedActor, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for q.actors.Len() == 0 && !q.closed {
+		q.cond.Wait()
+	}
+
+	if q.closed {
+		return nil, false
+	}
+
+	return q.actors.Remove(q.actors.Front()).(*dispatchedActor), true
+}
+
+func (q *runQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+
+	q.cond.Broadcast()
+}
+
+func (q *runQueue) work() {
+	for {
+		a, ok := q.pop()
+
+		if !ok {
+			return
+		}
+
+		a.step()
+	}
+}
+
+const (
+	actorIdle int32 = iota
+	actorScheduled
+	actorStopped
+)
+
+type dispatchedActor struct {
+	state      *atomic.Int32
+	queue      *runQueue
+	pinned     bool
+	throughput int
+	pid        *Pid
+	//process is called for every element in the mailbox of the
+	//actor and returns false as soon as the actor went down
+	process func(elem interface{}) bool
+}
+
+//schedule puts the actor into the run queue unless it is already
+//in there (or being run by a worker)
+func (a *dispatchedActor) schedule() {
+	if a.state.CAS(actorIdle, actorScheduled) {
+		a.queue.push(a)
+	}
+}
+
+func (a *dispatchedActor) step() {
+	for i := 0; i < a.throughput; i++ {
+		elem, ok := a.pid.mailbox.Pop()
+
+		if !ok {
+			break
+		}
+
+		if !a.process(elem) {
+			a.state.Store(actorStopped)
+
+			if a.pinned {
+				a.queue.close()
+			}
+
+			return
+		}
+	}
+
+	a.state.Store(actorIdle)
+
+	//somebody might have pushed right before we went idle
+	if a.pid.mailbox.Pending() {
+		a.schedule()
+	}
+}
+
+//System requests (quit, monitor, link, etc.) to actors that run on a
+//Dispatcher go through the system lane of their mailbox.
+
+type quitSignal struct{}
+
+type monitorSignal struct {
+	monitor *Pid
+}
+
+type demonitorSignal struct {
+	monitor *Pid
+}
+
+type linkSignal struct {
+	from *Pid
+}
+
+type unlinkSignal struct {
+	from *Pid
+}
+
+type idleSignal struct {
+	generation uint64
+}
+
+//dispatchedPid returns the local instance of a PID if the actor runs
+//on a Dispatcher (the PID might have been sent to a remote machine
+//and back) and nil otherwise
+func dispatchedPid(pid *Pid) *Pid {
+	if pid.dispatched {
+		return pid
+	}
+
+	//done is only set on the instance the actor was spawned with
+	//(just like dispatched, it never changes after spawn)
+	if pid.MachineId != machineId || pid.done != nil {
+		return nil
+	}
+
+	if p, ok := getByPidId(pid.Id); ok && p.dispatched {
+		return p
+	}
+
+	return nil
+}
+
+//pushSignal pushes a system request into the system lane of the
+//mailbox of an actor that runs on a Dispatcher. pushSignal returns
+//false if the actor is already down.
+func (pid *Pid) pushSignal(signal interface{}) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			//This happens if we push to the mailbox while the actor is being closed
+			ok = false
+		}
+	}()
+
+	mb := pid.mailbox
+
+	if mb == nil {
+		return false
+	}
+
+	mb.PushSystem(signal)
+
+	return true
+}
+
+func (c *Context) receiveDispatched(matcher Matcher, timeout time.Duration) (Message, error) {
+	skipped := make([]interface{}, 0)
+
+	defer func() {
+		//put everything we didn't want back to where it was
+		if len(skipped) != 0 {
+			c.mailbox.PushFront(skipped...)
+		}
+	}()
+
+	var timeoutChan <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		timeoutChan = timer.C
+	}
+
+	for {
+		elem, ok := c.mailbox.Pop()
+
+		if !ok {
+			select {
+			case <-c.mailbox.Wait():
+			case <-timeoutChan:
+				return nil, ErrReceiveTimeout
+			}
+
+			continue
+		}
+
+		switch s := elem.(type) {
+		case quitSignal:
+			logger.Info("actor received quit event while receiving",
+				"pid", c.self.Id)
+			panic(quitAction{reason: ExitReason{Kind: KILLED_EXIT}})
+		case monitorSignal:
+			handleMonitorRequest(c.self, s.monitor)
+		case demonitorSignal:
+			handleDemonitorRequest(c.self, s.monitor)
+		case linkSignal:
+			handleLinkRequest(c.self, s.from)
+		case unlinkSignal:
+			handleUnlinkRequest(c.self, s.from)
+		case ExitMessage:
+			if !c.handleExitSignal(s) {
+				continue
+			}
+
+			if matcher(s) {
+				return s, nil
+			}
+
+			skipped = append(skipped, localMessage{message: s})
+		case localMessage:
+			if matcher(s.message) {
+				if s.stale() {
+					continue
+				}
+
+				metrics.RecordReceive(c.self.Id)
+				return s.message, nil
+			}
+
+			skipped = append(skipped, s)
+		default:
+			//e.g. an idleSignal, the actor loop takes care of it
+			skipped = append(skipped, s)
+		}
+	}
+}
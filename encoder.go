@@ -10,7 +10,7 @@ import (
 func encodeValue(messageType string, value interface{}) (ret map[string]interface{}, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("panic while encoding value %x", r)
+			err = fmt.Errorf("panic while encoding value: %v", r)
 		}
 	}()
 
@@ -27,10 +27,20 @@ func encodeValue(messageType string, value interface{}) (ret map[string]interfac
 	for i := 0; i < val.NumField(); i++ {
 		field := val.Field(i)
 
+		//Unexported fields (e.g. the channels of a Pid) can't be read and aren't sent over the wire
+		if valType.Field(i).PkgPath != "" {
+			continue
+		}
+
 		switch field.Kind() {
-		case reflect.Struct:
-			fallthrough
 		case reflect.Ptr:
+			if field.IsNil() {
+				ret[valType.Field(i).Name] = nil
+				continue
+			}
+
+			fallthrough
+		case reflect.Struct:
 			v, err := encodeValue(messageType, field.Interface())
 			if err != nil {
 				return nil, err
@@ -54,31 +64,126 @@ func decodeValue(messageType string, data map[string]interface{}) (interface{},
 	return decodeValueByInterface(registryVal, data)
 }
 
-func decodeValueByInterface(template interface{}, data map[string]interface{}) (interface{}, error) {
+func decodeValueByInterface(template interface{}, data map[string]interface{}) (ret interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while decoding value: %v", r)
+		}
+	}()
+
 	retType := reflect.ValueOf(template).Type()
-	ret := reflect.New(retType).Elem()
+	decoded := reflect.New(retType).Elem()
 
 	for s, i := range data {
-		val := reflect.ValueOf(i)
+		field := decoded.FieldByName(s)
 
-		switch ret.FieldByName(s).Kind() {
-		case reflect.Struct:
-			b, ok := i.(map[string]interface{})
-			if !ok {
-				return nil, errors.New(s + " is " + reflect.TypeOf(i).String() + " not map[string]interface{}")
+		if !field.IsValid() || i == nil {
+			//The field doesn't exist (anymore) or was nil when it was sent
+			continue
+		}
+
+		v, err := decodeField(s, field.Type(), i)
+
+		if err != nil {
+			return nil, err
+		}
+
+		field.Set(v)
+	}
+
+	return decoded.Interface(), nil
+}
+
+//decodeField turns a value msgpack decoded into a value of the field type
+func decodeField(name string, fieldType reflect.Type, i interface{}) (reflect.Value, error) {
+	val := reflect.ValueOf(i)
+
+	if val.Type().AssignableTo(fieldType) {
+		//e.g. a time.Time (msgpack has an extension for those)
+		return val, nil
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Ptr:
+		if fieldType.Elem().Kind() != reflect.Struct {
+			break
+		}
+
+		v, err := decodeField(name, fieldType.Elem(), i)
+
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		p := reflect.New(fieldType.Elem())
+		p.Elem().Set(v)
+
+		return p, nil
+	case reflect.Struct:
+		b, ok := i.(map[string]interface{})
+		if !ok {
+			return reflect.Value{}, errors.New(name + " is " + val.Type().String() + " not map[string]interface{}")
+		}
+
+		v, err := decodeValueByInterface(reflect.New(fieldType).Elem().Interface(), b)
+
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(v), nil
+	case reflect.Slice:
+		//msgpack decodes every slice as []interface{}
+		elems, ok := i.([]interface{})
+		if !ok {
+			break
+		}
+
+		slice := reflect.MakeSlice(fieldType, len(elems), len(elems))
+
+		for n, elem := range elems {
+			if elem == nil {
+				continue
 			}
 
-			v, err := decodeValueByInterface(reflect.New(ret.FieldByName(s).Type()).Elem().Interface(), b)
+			v, err := decodeField(name, fieldType.Elem(), elem)
 
 			if err != nil {
-				return nil, err
+				return reflect.Value{}, err
 			}
 
-			ret.FieldByName(s).Set(reflect.ValueOf(v))
-		default:
-			ret.FieldByName(s).Set(val)
+			slice.Index(n).Set(v)
 		}
+
+		return slice, nil
+	}
+
+	if convertible(val.Type(), fieldType) {
+		return val.Convert(fieldType), nil
+	}
+
+	//setting the field panics with a readable error
+	return val, nil
+}
+
+//convertible returns true if a value msgpack decoded as from was sent as to.
+//msgpack doesn't know about named types (e.g. ExitKind) or the exact size of
+//a number, anything else (e.g. an int that would become a string) isn't converted.
+func convertible(from reflect.Type, to reflect.Type) bool {
+	if isNumber(from.Kind()) && isNumber(to.Kind()) {
+		return true
+	}
+
+	return from.Kind() == to.Kind() && from.ConvertibleTo(to)
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
 	}
 
-	return ret.Interface(), nil
+	return false
 }
@@ -3,6 +3,7 @@ package quacktors
 import (
 	"github.com/Azer0s/quacktors/typeregister"
 	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
 	"testing"
 )
 
@@ -61,3 +62,59 @@ func TestNestedEncode(t *testing.T) {
 
 	assert.Equal(t, val, valDec)
 }
+
+func TestEncodeDownMessage(t *testing.T) {
+	val := DownMessage{
+		Who: &Pid{Id: "foo", MachineId: "bar"},
+		Reason: ExitReason{
+			Kind:  PANIC_EXIT,
+			Value: "oh no",
+			Stack: "main.go:42",
+		},
+	}
+
+	typeregister.Store(val.Type(), val)
+	b, err := encodeValue(val.Type(), val)
+	assert.NoError(t, err)
+
+	//simulate the trip over the wire
+	encoded, _ := msgpack.Marshal(b)
+	data := make(map[string]interface{})
+	_ = msgpack.Unmarshal(encoded, &data)
+
+	valDec, err := decodeValue(val.Type(), data)
+	assert.NoError(t, err)
+
+	assert.Equal(t, val.Who.String(), valDec.(DownMessage).Who.String())
+	assert.Equal(t, val.Reason, valDec.(DownMessage).Reason)
+}
+
+func TestDecodePanic(t *testing.T) {
+	typeregister.Store("test", test{})
+
+	_, err := decodeValue("test", map[string]interface{}{"Foo": []interface{}{"quack"}})
+
+	assert.Error(t, err)
+	//the panic is readable (and not hex-encoded)
+	assert.Contains(t, err.Error(), "is not assignable to type string")
+}
+
+func TestEncodePanic(t *testing.T) {
+	_, err := encodeValue("test", 42)
+
+	assert.Error(t, err)
+	//the panic is readable (and not hex-encoded)
+	assert.Contains(t, err.Error(), "call of reflect.Value.NumField on int Value")
+}
+
+func TestDecodeNoIntToString(t *testing.T) {
+	typeregister.Store("test", test{})
+
+	//an int is convertible to a string (as a rune) but it is still the wrong type
+	_, err := decodeValue("test", map[string]interface{}{"Foo": int64(65)})
+	assert.Error(t, err)
+
+	valDec, err := decodeValue("test", map[string]interface{}{"Foo": "A", "Bar": int8(4)})
+	assert.NoError(t, err)
+	assert.Equal(t, test{Foo: "A", Bar: 4}, valDec)
+}
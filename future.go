@@ -0,0 +1,169 @@
+package quacktors
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+//ErrAskTimeout is returned by Future.Await if the asked
+//actor didn't reply within the timeout period.
+var ErrAskTimeout = errors.New("ask timed out")
+
+//ErrAskTargetDown is returned by Future.Await if the asked
+//actor went down (or was already dead) before it replied.
+var ErrAskTargetDown = errors.New("asked actor went down before replying")
+
+//A Future is returned by Context.Ask and holds the reply
+//of the asked actor as soon as it arrives. A Future can
+//either be awaited (see Await) or be piped into the mailbox
+//of an actor (see PipeTo).
+type Future struct {
+	id       string
+	done     chan bool
+	once     *sync.Once
+	response Message
+	err      error
+	//to is the asked actor
+	to *Pid
+}
+
+//futures stores the pending futures by their id. The reply target of
+//an Ask is a PID that has the id of the Future instead of an actor,
+//so replies (and the DownMessage of the asked actor) that are
+//delivered to it resolve the Future instead (see deliverLocal).
+var futures = make(map[string]*Future)
+var futuresMu = &sync.RWMutex{}
+
+func newFuture() *Future {
+	return &Future{
+		id:   uuidString(),
+		done: make(chan bool),
+		once: &sync.Once{},
+	}
+}
+
+func (f *Future) resolve(response Message, err error) {
+	f.once.Do(func() {
+		futuresMu.Lock()
+		delete(futures, f.id)
+		futuresMu.Unlock()
+
+		f.response = response
+		f.err = err
+		close(f.done)
+	})
+}
+
+func isPendingFuture(id string) bool {
+	futuresMu.RLock()
+	defer futuresMu.RUnlock()
+
+	_, ok := futures[id]
+	return ok
+}
+
+//resolveFuture resolves the pending Future with the id and
+//returns false if there is no such Future.
+func resolveFuture(id string, message Message) bool {
+	futuresMu.RLock()
+	f, ok := futures[id]
+	futuresMu.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	if d, ok := message.(DownMessage); ok && d.Who.Is(f.to) {
+		f.resolve(nil, ErrAskTargetDown)
+		return true
+	}
+
+	f.resolve(message, nil)
+	return true
+}
+
+//Id returns the ID of the Future. The ID is also set on
+//the FutureResultMessage a Future pipes into a mailbox.
+func (f *Future) Id() string {
+	return f.id
+}
+
+//Await blocks until the asked actor has replied and returns
+//the reply. If the asked actor didn't reply in time or went
+//down before replying, Await returns ErrAskTimeout or
+//ErrAskTargetDown respectively. Note that an actor that
+//awaits a Future it got by asking itself will always run
+//into the timeout (use PipeTo instead).
+func (f *Future) Await() (Message, error) {
+	<-f.done
+	return f.response, f.err
+}
+
+//PipeTo sends the result of the Future to an actor as a
+//FutureResultMessage as soon as the Future is resolved.
+//This way, an actor can ask another actor (or itself)
+//without blocking by piping the Future to ctx.Self().
+func (f *Future) PipeTo(pid *Pid) {
+	go func() {
+		<-f.done
+
+		result := FutureResultMessage{
+			Id:       f.id,
+			Response: f.response,
+		}
+
+		if f.err != nil {
+			result.Error = f.err.Error()
+		}
+
+		_ = doSend(pid, result, nil)
+	}()
+}
+
+func startAsk(c *Context, to *Pid, message Message, timeout time.Duration) *Future {
+	f := newFuture()
+	f.to = to
+
+	replyTo := &Pid{MachineId: machineId, Id: f.id}
+
+	futuresMu.Lock()
+	futures[f.id] = f
+	futuresMu.Unlock()
+
+	//a timeout of 0 or less waits indefinitely (just like Context.Receive)
+	var timer *time.Timer
+
+	if timeout > 0 {
+		timer = time.AfterFunc(timeout, func() {
+			f.resolve(nil, ErrAskTimeout)
+		})
+	}
+
+	//the reply target monitors the asked actor so the Future fails as soon as it goes down
+	monitorCtx := &Context{
+		self:     replyTo,
+		Logger:   contextLogger{pid: f.id},
+		deferred: make([]func(), 0),
+	}
+
+	go func() {
+		//the asked actor might be the asking actor itself, which
+		//can't handle the monitor request before Ask has returned
+		monitor := monitorCtx.Monitor(to)
+
+		<-f.done
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if f.err != ErrAskTargetDown {
+			monitor.Abort()
+		}
+	}()
+
+	_ = c.send(to, message, replyTo)
+
+	return f
+}
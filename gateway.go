@@ -1,14 +1,17 @@
 package quacktors
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"github.com/Azer0s/qpmd"
 	"github.com/Azer0s/quacktors/metrics"
 	"github.com/opentracing/opentracing-go"
 	"github.com/vmihailenco/msgpack/v5"
 	"io"
 	"net"
+	"sync"
 )
 
 /*
@@ -20,13 +23,15 @@ func startMessageGateway() (uint16, error) {
 	return startServer(func(portChan chan int, errorChan chan error) {
 		logger.Info("starting message gateway")
 
-		listener, err := net.Listen("tcp", ":0")
+		listener, err := listen()
 
 		if err != nil {
 			errorChan <- errors.New("couldn't start message gateway on random port")
 			return
 		}
 
+		messageGatewayListener = listener
+
 		port := listener.Addr().(*net.TCPAddr).Port
 
 		logger.Debug("started message gatway",
@@ -37,9 +42,13 @@ func startMessageGateway() (uint16, error) {
 		for {
 			conn, err := listener.Accept()
 			if err != nil {
+				if shuttingDown.Load() {
+					logger.Info("stopped accepting connections to message gateway")
+					return
+				}
+
 				logger.Warn("there was an error while accepting new connection to message gateway",
 					"error", err)
-				_ = conn.Close()
 				continue
 			}
 
@@ -66,10 +75,31 @@ func handleMessageClient(conn net.Conn) {
 	logger.Info("handling new message gateway connection from remote machine",
 		"client", c)
 
+	_, err := serverHandshake(conn)
+
+	if errors.Is(err, ErrAuthenticationFailed) {
+		logger.Warn("rejected connection to the message gateway, remote machine failed to authenticate",
+			"client", c)
+		return
+	}
+
+	if err != nil {
+		logger.Warn("there was an error while handling the initial hello request to the message gateway",
+			"client", c,
+			"error", err)
+		return
+	}
+
+	//frames are decoded right off the stream, the buffer just saves us a syscall per frame
+	reader := bufio.NewReader(conn)
+
+	//messages are decoded in parallel but delivered in order
+	//(per sender and receiver, just like local messages)
+	chain := newDeliveryChain()
+
 	for {
-		buf := make([]byte, 4096)
-		n, err := conn.Read(buf)
-		if n == 0 || err != nil {
+		b, err := readFrame(reader)
+		if err != nil {
 			if errors.Is(err, io.EOF) {
 				logger.Info("remote machine disconnected from message gateway",
 					"client", c)
@@ -83,7 +113,7 @@ func handleMessageClient(conn net.Conn) {
 
 		msgData := make(map[string]interface{})
 
-		err = msgpack.Unmarshal(buf[:n], &msgData)
+		err = msgpack.Unmarshal(b, &msgData)
 		if err != nil {
 			logger.Warn("there was an error while unmarshalling incoming message from remote machine",
 				"client", c,
@@ -91,7 +121,12 @@ func handleMessageClient(conn net.Conn) {
 			return
 		}
 
+		key := deliveryKey(msgData)
+		previous, done := chain.enqueue(key)
+
 		go func(data map[string]interface{}) {
+			defer chain.finish(key, previous, done)
+
 			pidId := data[toVal].(string)
 			toPid, ok := getByPidId(pidId)
 
@@ -99,6 +134,11 @@ func handleMessageClient(conn net.Conn) {
 				"client", c,
 				"pid", pidId)
 
+			if !ok && isPendingFuture(pidId) {
+				//the message is a reply to an Ask (see resolveFuture)
+				toPid, ok = &Pid{MachineId: machineId, Id: pidId}, true
+			}
+
 			if !ok {
 				logger.Warn("couldn't find pid id target of remote message on local system",
 					"client", c,
@@ -123,7 +163,7 @@ func handleMessageClient(conn net.Conn) {
 
 				m, ok := getMachine(d.Who.MachineId)
 
-				if ok && m.connected {
+				if ok && m.isConnected() {
 					m.removeRemoteMonitor(remoteMonitorTuple{
 						From: toPid,
 						To:   d.Who,
@@ -136,23 +176,117 @@ func handleMessageClient(conn net.Conn) {
 				spanContext, _ = opentracing.GlobalTracer().Extract(opentracing.Binary, bytes.NewBuffer(spanCtxBytes))
 			}
 
+			var sender *Pid = nil
+			if senderData, ok := data[senderVal].(map[string]interface{}); ok {
+				sender, err = parsePid(senderData)
+
+				if err != nil {
+					logger.Warn("there was an error while decoding the sender PID of incoming message from remote machine",
+						"client", c,
+						"pid", pidId,
+						"error", err)
+					return
+				}
+			}
+
+			var replyTo *Pid = nil
+			if replyToData, ok := data[replyToVal].(map[string]interface{}); ok {
+				replyTo, err = parsePid(replyToData)
+
+				if err != nil {
+					logger.Warn("there was an error while decoding the reply PID of incoming message from remote machine",
+						"client", c,
+						"pid", pidId,
+						"error", err)
+					return
+				}
+			}
+
+			//wait until the message before this one has been delivered
+			<-previous
+
 			metrics.RecordReceiveRemote(toPid.Id)
-			doSend(toPid, msg, spanContext)
+			_ = deliver(toPid, localMessage{
+				message:     msg,
+				spanContext: spanContext,
+				sender:      sender,
+				replyTo:     replyTo,
+			})
 		}(msgData)
 	}
 }
 
+//A deliveryChain orders the deliveries of the messages on a message
+//gateway connection. Every message waits for the message that was
+//received before it and had the same sender and receiver.
+type deliveryChain struct {
+	mu   *sync.Mutex
+	tail map[string]chan bool
+}
+
+func newDeliveryChain() *deliveryChain {
+	return &deliveryChain{
+		mu:   &sync.Mutex{},
+		tail: make(map[string]chan bool),
+	}
+}
+
+//enqueue returns a channel that is closed as soon as the previous message
+//with the same key is done and the channel to close once the message is done
+func (d *deliveryChain) enqueue(key string) (<-chan bool, chan bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	done := make(chan bool)
+
+	previous, ok := d.tail[key]
+	d.tail[key] = done
+
+	if !ok {
+		previous = make(chan bool)
+		close(previous)
+	}
+
+	return previous, done
+}
+
+func (d *deliveryChain) finish(key string, previous <-chan bool, done chan bool) {
+	//even if the message was never delivered (e.g. because it couldn't be
+	//decoded) it must not overtake the message before it
+	<-previous
+	close(done)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.tail[key] == done {
+		delete(d.tail, key)
+	}
+}
+
+func deliveryKey(data map[string]interface{}) string {
+	key, _ := data[toVal].(string)
+
+	if sender, ok := data[senderVal].(map[string]interface{}); ok {
+		key += fmt.Sprintf("_%v@%v", sender["Id"], sender["MachineId"])
+	}
+
+	return key
+}
+
 func startGeneralPurposeGateway() (uint16, error) {
 	return startServer(func(portChan chan int, errorChan chan error) {
 		logger.Info("starting general purpose gateway")
 
-		listener, err := net.Listen("tcp", ":0")
+		listener, err := listen()
 
 		if err != nil {
 			errorChan <- errors.New("couldn't start general purpose gateway on random port")
 			return
 		}
 
+		gpGatewayListener = listener
+
 		port := listener.Addr().(*net.TCPAddr).Port
 
 		logger.Debug("started general purpose gatway",
@@ -171,9 +305,13 @@ func startGeneralPurposeGateway() (uint16, error) {
 
 			conn, err := listener.Accept()
 			if err != nil {
+				if shuttingDown.Load() {
+					logger.Info("stopped accepting connections to general purpose gateway")
+					return
+				}
+
 				logger.Warn("there was an error while accepting new connection to general purpose gateway",
 					"error", err)
-				_ = conn.Close()
 				continue
 			}
 
@@ -200,10 +338,16 @@ func handleGpClient(conn net.Conn) {
 	logger.Info("handling new general purpose gateway connection from remote machine",
 		"client", c)
 
-	req, err := readRequest(conn)
+	req, err := serverHandshake(conn)
+
+	if errors.Is(err, ErrAuthenticationFailed) {
+		logger.Warn("rejected connection to the general purpose gateway, remote machine failed to authenticate",
+			"client", c)
+		return
+	}
 
 	if err != nil {
-		logger.Warn("there was an error while reading the initial hello request to the general purpose gateway",
+		logger.Warn("there was an error while handling the initial hello request to the general purpose gateway",
 			"client", c,
 			"error", err)
 		return
@@ -224,18 +368,6 @@ func handleGpClient(conn net.Conn) {
 		GeneralPurposePort: req.Data[qpmd.GP_GATEWAY_PORT].(uint16),
 	}
 
-	err = sendResponse(conn, qpmd.Response{
-		ResponseType: qpmd.RESPONSE_OK,
-		Data:         make(map[string]interface{}),
-	})
-
-	if err != nil {
-		logger.Warn("there was an error while responding to the initial hello request to the general purpose gateway",
-			"client", c,
-			"error", err)
-		return
-	}
-
 	//if this is a back-connect, skip right to handling requests
 	//if not, propagate the machine to all connected machines
 	err = propagateMachineIfNotExists(m)
@@ -251,7 +383,7 @@ func handleGpClient(conn net.Conn) {
 		machine, ok := getMachine(m.MachineId)
 
 		if ok {
-			if machine.connected {
+			if machine.isConnected() {
 				machine.stop()
 			}
 		}
@@ -266,6 +398,16 @@ func handleGpClient(conn net.Conn) {
 		}
 	}()
 
+	//some requests have to be handled in the order they were received in (see isOrderedRequest)
+	orderedRequests := make(chan qpmd.Request, 100)
+	defer close(orderedRequests)
+
+	go func() {
+		for r := range orderedRequests {
+			handleGpRequest(r, c)
+		}
+	}()
+
 	for {
 		r, err := readRequest(conn)
 
@@ -281,10 +423,36 @@ func handleGpClient(conn net.Conn) {
 			return
 		}
 
+		if isOrderedRequest(r.RequestType) {
+			orderedRequests <- r
+			continue
+		}
+
 		go handleGpRequest(r, c)
 	}
 }
 
+//isOrderedRequest returns true if requests of the type are handled one
+//after another in the order they were received in. An Ask demonitors its
+//target right after the reply, so the demonitor request could otherwise
+//overtake the monitor request. The same goes for an exit signal and the
+//link request before it (the exit signal would be ignored because the
+//pids aren't linked yet). An unregister (or leave) request that overtakes
+//its register (or join) request would leave a stale global name (or
+//process group member) behind.
+func isOrderedRequest(requestType qpmd.RequestType) bool {
+	switch requestType {
+	case monitorMessageType, demonitorMessageType, linkMessageType, unlinkMessageType, exitMessageType:
+		return true
+	case globalRegisterMessageType, globalUnregisterMessageType:
+		return true
+	case groupJoinMessageType, groupLeaveMessageType:
+		return true
+	}
+
+	return false
+}
+
 func propagateMachineIfNotExists(m *Machine) error {
 	if _, ok := getMachine(m.MachineId); !ok {
 		err := m.connect()
@@ -352,9 +520,11 @@ func handleGpRequest(req qpmd.Request, client string) {
 		p, ok := getByPidId(toPid.Id)
 
 		if !ok {
-			logger.Warn("couldn't find pid id target of remote monitor request on local system",
+			logger.Warn("couldn't find pid id target of remote monitor request on local system, sending out DownMessage to monitor immediately",
 				"client", client,
 				"pid", toPid.Id)
+
+			doSend(fromPid, DownMessage{Who: toPid, Reason: ExitReason{Kind: NO_PROC_EXIT}}, nil)
 			return
 		}
 
@@ -398,10 +568,167 @@ func handleGpRequest(req qpmd.Request, client string) {
 
 		name := fromPid.String() + "_" + toPid.String()
 
-		remoteMonitorQuitAbortables[name].Abort()
+		//the monitored actor might already be down
+		abortable, ok := remoteMonitorQuitAbortables[name]
+		if !ok {
+			return
+		}
+
+		abortable.Abort()
 
 		delete(remoteMonitorQuitAbortables, name)
 
+	case linkMessageType, unlinkMessageType:
+		fromPid, err := parsePid(req.Data[fromVal].(map[string]interface{}))
+
+		if err != nil {
+			logger.Warn("there was an error while trying to decode PID data (link) for link request from remote machine",
+				"client", client,
+				"error", err)
+			return
+		}
+
+		toPid, err := parsePid(req.Data[toVal].(map[string]interface{}))
+
+		if err != nil {
+			logger.Warn("there was an error while trying to decode PID data (linked PID) for link request from remote machine",
+				"client", client,
+				"error", err)
+			return
+		}
+
+		p, ok := getByPidId(toPid.Id)
+
+		if !ok {
+			if req.RequestType == unlinkMessageType {
+				return
+			}
+
+			logger.Warn("couldn't find pid id target of remote link request on local system, sending out exit signal immediately",
+				"client", client,
+				"pid", toPid.Id)
+
+			sendExitSignal(toPid, fromPid, ExitReason{Kind: NO_PROC_EXIT})
+			return
+		}
+
+		//the request has to reach the actor before we handle the next one
+		if req.RequestType == linkMessageType {
+			pushLinkRequest(fromPid, p)
+		} else {
+			pushUnlinkRequest(fromPid, p)
+		}
+
+	case exitMessageType:
+		fromPid, err := parsePid(req.Data[fromVal].(map[string]interface{}))
+
+		if err != nil {
+			logger.Warn("there was an error while trying to decode PID data (exiting PID) for exit signal from remote machine",
+				"client", client,
+				"error", err)
+			return
+		}
+
+		toPid, err := parsePid(req.Data[toVal].(map[string]interface{}))
+
+		if err != nil {
+			logger.Warn("there was an error while trying to decode PID data (linked PID) for exit signal from remote machine",
+				"client", client,
+				"error", err)
+			return
+		}
+
+		reason, err := decodeValueByInterface(ExitReason{}, req.Data[reasonVal].(map[string]interface{}))
+
+		if err != nil {
+			logger.Warn("there was an error while trying to decode exit reason for exit signal from remote machine",
+				"client", client,
+				"error", err)
+			return
+		}
+
+		logger.Debug("received exit signal from remote machine for pid on local system",
+			"client", client,
+			"pid", toPid.Id)
+
+		pushExitSignal(fromPid, toPid, reason.(ExitReason))
+
+	case globalRegisterMessageType, globalUnregisterMessageType:
+		pid, err := parsePid(req.Data[pidVal].(map[string]interface{}))
+
+		if err != nil {
+			logger.Warn("there was an error while trying to decode PID data for global name from remote machine",
+				"client", client,
+				"error", err)
+			return
+		}
+
+		logger.Debug("received global name from remote machine",
+			"client", client,
+			"name", req.Data[nameVal],
+			"gpid", pid.String())
+
+		handleRemoteGlobalName(remoteGlobalNameTuple{
+			Name:       req.Data[nameVal].(string),
+			Pid:        pid,
+			Unregister: req.RequestType == globalUnregisterMessageType,
+		})
+
+	case groupJoinMessageType, groupLeaveMessageType:
+		pid, err := parsePid(req.Data[pidVal].(map[string]interface{}))
+
+		if err != nil {
+			logger.Warn("there was an error while trying to decode PID data for process group membership from remote machine",
+				"client", client,
+				"error", err)
+			return
+		}
+
+		logger.Debug("received process group membership from remote machine",
+			"client", client,
+			"group", req.Data[groupVal],
+			"gpid", pid.String())
+
+		handleRemoteGroupMembership(remoteGroupTuple{
+			Group: req.Data[groupVal].(string),
+			Pid:   pid,
+			Leave: req.RequestType == groupLeaveMessageType,
+		})
+
+	case processInfoMessageType:
+		pidId := req.Data[pidVal].(string)
+		requestId := req.Data[requestIdVal].(string)
+
+		logger.Debug("received process info request from remote machine for pid on local system",
+			"client", client,
+			"pid", pidId)
+
+		m, ok := getMachine(req.Data[machineVal].(string))
+
+		if !ok || !m.isConnected() {
+			logger.Warn("couldn't find requesting machine of process info request",
+				"client", client,
+				"pid", pidId)
+			return
+		}
+
+		info := localProcessInfo(pidId)
+
+		m.processInfoChan <- remoteProcessInfoTuple{
+			RequestId: requestId,
+			Pid:       info.Pid,
+			Info:      &info,
+		}
+
+	case processInfoResultMessageType:
+		err := handleRemoteProcessInfo(req.Data[requestIdVal].(string), req.Data[infoVal].(map[string]interface{}))
+
+		if err != nil {
+			logger.Warn("there was an error while trying to decode process info from remote machine",
+				"client", client,
+				"error", err)
+		}
+
 	case newConnectionMessageType:
 		m, err := parseMachine(req.Data[machineVal].(map[string]interface{}))
 
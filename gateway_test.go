@@ -0,0 +1,441 @@
+package quacktors
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Azer0s/qpmd"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFrame(t *testing.T) {
+	RootContext()
+
+	buf := &bytes.Buffer{}
+
+	//back-to-back frames end up in the same buffer (just like TCP would coalesce them)
+	assert.NoError(t, writeFrame(buf, []byte("foo")))
+	assert.NoError(t, writeFrame(buf, []byte{}))
+	assert.NoError(t, writeFrame(buf, []byte("bar")))
+
+	for _, expected := range []string{"foo", "", "bar"} {
+		b, err := readFrame(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(b))
+	}
+
+	//a frame that is cut off is an error, not a short message
+	assert.NoError(t, writeFrame(buf, []byte("foo")))
+	buf.Truncate(buf.Len() - 1)
+	_, err := readFrame(buf)
+	assert.Error(t, err)
+
+	old := maxFrameSize
+	maxFrameSize = 4
+	defer func() {
+		maxFrameSize = old
+	}()
+
+	buf.Reset()
+	assert.Equal(t, ErrFrameTooLarge, writeFrame(buf, []byte("foobar")))
+	assert.Equal(t, 0, buf.Len())
+
+	maxFrameSize = 8
+	assert.NoError(t, writeFrame(buf, []byte("foobar")))
+
+	maxFrameSize = 4
+	_, err = readFrame(buf)
+	assert.Equal(t, ErrFrameTooLarge, err)
+}
+
+func TestFramedRequest(t *testing.T) {
+	RootContext()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	large := strings.Repeat("quack", 10000)
+
+	go func() {
+		for i := 0; i < 3; i++ {
+			_ = sendRequest(client, qpmd.Request{
+				RequestType: qpmd.REQUEST_LOOKUP,
+				Data: map[string]interface{}{
+					handler: large,
+				},
+			})
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		req, err := readRequest(server)
+		assert.NoError(t, err)
+		assert.Equal(t, qpmd.REQUEST_LOOKUP, req.RequestType)
+		assert.Equal(t, large, req.Data[handler])
+	}
+}
+
+func TestMessageGatewayFraming(t *testing.T) {
+	rootCtx := RootContext()
+
+	const count = 500
+	large := strings.Repeat("quack", 200*1024)
+
+	r := startNode(t)
+
+	echo, err := r.Remote("echo")
+	assert.NoError(t, err)
+
+	received := make(chan string, count+1)
+
+	//every message goes to the other node and comes back
+	p := Spawn(func(ctx *Context, message Message) {
+		v := message.(GenericMessage).Value.(string)
+
+		if v != "start" {
+			received <- v
+			return
+		}
+
+		ctx.Send(echo, GenericMessage{Value: large})
+
+		for i := 0; i < count; i++ {
+			ctx.Send(echo, GenericMessage{Value: "small"})
+		}
+	})
+
+	rootCtx.Send(p, GenericMessage{Value: "start"})
+
+	timeout := time.After(10 * time.Second)
+	smalls := 0
+	larges := 0
+
+	for smalls+larges != count+1 {
+		select {
+		case v := <-received:
+			if v == large {
+				larges++
+			} else {
+				assert.Equal(t, "small", v)
+				smalls++
+			}
+		case <-timeout:
+			t.Fatalf("only received %d of %d messages", smalls+larges, count+1)
+		}
+	}
+
+	assert.Equal(t, 1, larges)
+	assert.Equal(t, count, smalls)
+
+	rootCtx.Kill(p)
+
+	Run()
+}
+
+func TestMessageGatewayOrdering(t *testing.T) {
+	rootCtx := RootContext()
+
+	const count = 1000
+
+	r := startNode(t)
+
+	echo, err := r.Remote("echo")
+	assert.NoError(t, err)
+
+	padding := strings.Repeat("quack", 1000)
+	done := make(chan string, 2)
+
+	//every sender gets its messages back from the other node in the order it sent them
+	sender := func(name string) *Pid {
+		next := 0
+
+		return Spawn(func(ctx *Context, message Message) {
+			if message.(GenericMessage).Value == "start" {
+				for i := 0; i < count; i++ {
+					//messages of different sizes take different amounts of time to decode
+					value := []interface{}{int64(i)}
+					if i%3 == 0 {
+						value = append(value, padding)
+					}
+
+					ctx.Send(echo, GenericMessage{Value: value})
+				}
+
+				return
+			}
+
+			value := int(message.(GenericMessage).Value.([]interface{})[0].(int64))
+			assert.Equal(t, next, value, "message from %s out of order", name)
+			next = value + 1
+
+			if next == count {
+				done <- name
+				ctx.Quit()
+			}
+		})
+	}
+
+	senders := []*Pid{sender("a"), sender("b")}
+
+	for _, s := range senders {
+		rootCtx.Send(s, GenericMessage{Value: "start"})
+	}
+
+	timeout := time.After(10 * time.Second)
+
+	for range senders {
+		select {
+		case <-done:
+		case <-timeout:
+			for _, s := range senders {
+				rootCtx.Kill(s)
+			}
+
+			t.Fatal("not all messages came back from the other node")
+		}
+	}
+
+	Run()
+}
+
+func TestGeneralPurposeGatewayLink(t *testing.T) {
+	rootCtx := RootContext()
+
+	f := newFlakyMachine(t)
+	defer f.close()
+
+	m := f.machine()
+	assert.NoError(t, m.connect())
+	registerMachine(m)
+
+	//requests on this connection are handled by our own general purpose gateway
+	conn := f.connectBack(t, m)
+
+	remote := &Pid{MachineId: "flaky", Id: "remote"}
+	exits := make(chan ExitMessage, 1)
+
+	//a local actor links to a remote actor
+	trapping := SpawnWithInit(func(ctx *Context) {
+		ctx.TrapExits(true)
+		ctx.Link(remote)
+	}, func(ctx *Context, message Message) {
+		if e, ok := message.(ExitMessage); ok {
+			exits <- e
+		}
+	})
+
+	req := f.awaitRequest(t, linkMessageType)
+	assert.Equal(t, trapping.Id, req.Data[fromVal].(map[string]interface{})["Id"])
+	assert.Equal(t, "remote", req.Data[toVal].(map[string]interface{})["Id"])
+
+	//the remote actor goes down
+	assert.NoError(t, sendRequest(conn, qpmd.Request{
+		RequestType: exitMessageType,
+		Data: map[string]interface{}{
+			fromVal:   remote,
+			toVal:     trapping,
+			reasonVal: ExitReason{Kind: KILLED_EXIT},
+		},
+	}))
+
+	select {
+	case e := <-exits:
+		assert.Equal(t, remote.String(), e.Who.String())
+		assert.Equal(t, KILLED_EXIT, e.Reason.Kind)
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't receive ExitMessage")
+	}
+
+	//a remote actor links to a local actor
+	isLinked := make(chan bool)
+
+	linked := Spawn(func(ctx *Context, message Message) {
+		isLinked <- ctx.Self().isLinked(remote)
+	})
+
+	assert.NoError(t, sendRequest(conn, qpmd.Request{
+		RequestType: linkMessageType,
+		Data: map[string]interface{}{
+			fromVal: remote,
+			toVal:   linked,
+		},
+	}))
+
+	assert.Eventually(t, func() bool {
+		rootCtx.Send(linked, EmptyMessage{})
+		return <-isLinked
+	}, 5*time.Second, 5*time.Millisecond)
+
+	//the local actor goes down, so the remote actor receives an exit signal
+	rootCtx.Kill(linked)
+
+	req = f.awaitRequest(t, exitMessageType)
+	assert.Equal(t, linked.Id, req.Data[fromVal].(map[string]interface{})["Id"])
+	assert.Equal(t, "remote", req.Data[toVal].(map[string]interface{})["Id"])
+	assert.Equal(t, string(KILLED_EXIT), req.Data[reasonVal].(map[string]interface{})["Kind"])
+
+	rootCtx.Kill(trapping)
+
+	m.disconnect()
+
+	Run()
+}
+
+func TestGeneralPurposeGatewayLinkThenExit(t *testing.T) {
+	rootCtx := RootContext()
+
+	f := newFlakyMachine(t)
+	defer f.close()
+
+	m := f.machine()
+	assert.NoError(t, m.connect())
+	registerMachine(m)
+
+	conn := f.connectBack(t, m)
+
+	remote := &Pid{MachineId: "flaky", Id: "remote"}
+	exits := make(chan ExitMessage, 1)
+
+	//the exit signal right after the link request must not overtake it
+	for i := 0; i < 20; i++ {
+		linked := SpawnWithInit(func(ctx *Context) {
+			ctx.TrapExits(true)
+		}, func(ctx *Context, message Message) {
+			if e, ok := message.(ExitMessage); ok {
+				exits <- e
+				ctx.Quit()
+			}
+		})
+
+		assert.NoError(t, sendRequest(conn, qpmd.Request{
+			RequestType: linkMessageType,
+			Data: map[string]interface{}{
+				fromVal: remote,
+				toVal:   linked,
+			},
+		}))
+
+		assert.NoError(t, sendRequest(conn, qpmd.Request{
+			RequestType: exitMessageType,
+			Data: map[string]interface{}{
+				fromVal:   remote,
+				toVal:     linked,
+				reasonVal: ExitReason{Kind: KILLED_EXIT},
+			},
+		}))
+
+		select {
+		case e := <-exits:
+			assert.Equal(t, remote.String(), e.Who.String())
+		case <-time.After(5 * time.Second):
+			rootCtx.Kill(linked)
+			t.Fatal("didn't receive ExitMessage")
+		}
+	}
+
+	m.disconnect()
+
+	Run()
+}
+
+func TestGeneralPurposeGatewayGlobalNameOrder(t *testing.T) {
+	RootContext()
+
+	f := newFlakyMachine(t)
+	defer f.close()
+
+	m := f.machine()
+	assert.NoError(t, m.connect())
+	registerMachine(m)
+
+	conn := f.connectBack(t, m)
+
+	remote := &Pid{MachineId: "flaky", Id: "remote"}
+
+	globalName := func(requestType qpmd.RequestType, name string) {
+		assert.NoError(t, sendRequest(conn, qpmd.Request{
+			RequestType: requestType,
+			Data: map[string]interface{}{
+				nameVal: name,
+				pidVal:  remote,
+			},
+		}))
+	}
+
+	//an unregister request must not overtake the register request before it
+	for i := 0; i < 200; i++ {
+		name := fmt.Sprintf("gateway_order_%d", i)
+		globalName(globalRegisterMessageType, name)
+		globalName(globalUnregisterMessageType, name)
+	}
+
+	globalName(globalRegisterMessageType, "gateway_order_done")
+
+	assert.Eventually(t, func() bool {
+		_, ok := WhereisGlobal("gateway_order_done")
+		return ok
+	}, 5*time.Second, 5*time.Millisecond)
+
+	for i := 0; i < 200; i++ {
+		_, ok := WhereisGlobal(fmt.Sprintf("gateway_order_%d", i))
+		assert.False(t, ok)
+	}
+
+	m.disconnect()
+
+	Run()
+}
+
+func TestGeneralPurposeGatewayGroupOrder(t *testing.T) {
+	RootContext()
+
+	f := newFlakyMachine(t)
+	defer f.close()
+
+	m := f.machine()
+	assert.NoError(t, m.connect())
+	registerMachine(m)
+
+	conn := f.connectBack(t, m)
+
+	membership := func(requestType qpmd.RequestType, group string, pid *Pid) {
+		assert.NoError(t, sendRequest(conn, qpmd.Request{
+			RequestType: requestType,
+			Data: map[string]interface{}{
+				groupVal: group,
+				pidVal:   pid,
+			},
+		}))
+	}
+
+	//a leave request must not overtake the join request before it
+	for i := 0; i < 200; i++ {
+		membership(groupJoinMessageType, "gateway_order", &Pid{MachineId: "flaky", Id: fmt.Sprintf("remote_%d", i)})
+		membership(groupLeaveMessageType, "gateway_order", &Pid{MachineId: "flaky", Id: fmt.Sprintf("remote_%d", i)})
+	}
+
+	done := &Pid{MachineId: "flaky", Id: "done"}
+	membership(groupJoinMessageType, "gateway_order", done)
+
+	assert.Eventually(t, func() bool {
+		for _, member := range Members("gateway_order") {
+			if member.Is(done) {
+				return true
+			}
+		}
+
+		return false
+	}, 5*time.Second, 5*time.Millisecond)
+
+	members := Members("gateway_order")
+	assert.Len(t, members, 1)
+	assert.True(t, members[0].Is(done))
+
+	m.disconnect()
+
+	Run()
+}
@@ -0,0 +1,227 @@
+package quacktors
+
+import (
+	"sync"
+)
+
+//A ConflictPolicy decides which PID keeps a global name if two
+//machines registered the same name at the same time (see
+//Context.RegisterGlobal). Every machine resolves the conflict on
+//its own, so a ConflictPolicy has to be deterministic (i.e. return
+//the same PID no matter in which order existing and incoming are
+//passed) or the machines will disagree on who owns the name.
+type ConflictPolicy func(name string, existing *Pid, incoming *Pid) *Pid
+
+//KeepLowestPid is the default ConflictPolicy. It keeps the PID
+//with the lower machine ID (or the lower ID if both PIDs are on
+//the same machine).
+func KeepLowestPid(name string, existing *Pid, incoming *Pid) *Pid {
+	if existing.MachineId != incoming.MachineId {
+		if existing.MachineId < incoming.MachineId {
+			return existing
+		}
+
+		return incoming
+	}
+
+	if existing.Id < incoming.Id {
+		return existing
+	}
+
+	return incoming
+}
+
+//KillLoser wraps a ConflictPolicy and kills the PID that lost
+//the name if it is on the local machine.
+func KillLoser(policy ConflictPolicy) ConflictPolicy {
+	return func(name string, existing *Pid, incoming *Pid) *Pid {
+		winner := policy(name, existing, incoming)
+
+		loser := existing
+		if winner.Is(existing) {
+			loser = incoming
+		}
+
+		if loser.MachineId == machineId {
+			logger.Info("killing actor that lost global name conflict",
+				"name", name,
+				"pid", loser.Id)
+
+			if p, ok := getByPidId(loser.Id); ok {
+				go p.die()
+			}
+		}
+
+		return winner
+	}
+}
+
+var globalNames = make(map[string]*Pid)
+var globalNamesMu = &sync.RWMutex{}
+var conflictPolicy ConflictPolicy = KeepLowestPid
+
+//SetGlobalConflictPolicy sets the ConflictPolicy that is used
+//to resolve global name conflicts (KeepLowestPid by default).
+//All machines in a cluster should use the same policy.
+func SetGlobalConflictPolicy(policy ConflictPolicy) {
+	globalNamesMu.Lock()
+	defer globalNamesMu.Unlock()
+
+	conflictPolicy = policy
+}
+
+//WhereisGlobal returns the PID of the actor that is registered
+//under a global name (see Context.RegisterGlobal) and true, or
+//nil and false if there is no such actor. The actor can be on
+//any connected machine.
+func WhereisGlobal(name string) (*Pid, bool) {
+	globalNamesMu.RLock()
+	defer globalNamesMu.RUnlock()
+
+	pid, ok := globalNames[name]
+
+	return pid, ok
+}
+
+func registerGlobalName(name string, pid *Pid) error {
+	globalNamesMu.Lock()
+
+	if _, ok := globalNames[name]; ok {
+		globalNamesMu.Unlock()
+		return ErrNameTaken
+	}
+
+	globalNames[name] = pid
+	pid.globalNames = append(pid.globalNames, name)
+
+	globalNamesMu.Unlock()
+
+	logger.Info("registered global name",
+		"pid", pid.Id,
+		"name", name)
+
+	broadcastGlobalName(remoteGlobalNameTuple{Name: name, Pid: pid})
+
+	return nil
+}
+
+func unregisterGlobalName(name string, pid *Pid) {
+	globalNamesMu.Lock()
+
+	if p, ok := globalNames[name]; !ok || !p.Is(pid) {
+		globalNamesMu.Unlock()
+		return
+	}
+
+	delete(globalNames, name)
+
+	for i, n := range pid.globalNames {
+		if n == name {
+			pid.globalNames = append(pid.globalNames[:i], pid.globalNames[i+1:]...)
+			break
+		}
+	}
+
+	globalNamesMu.Unlock()
+
+	logger.Info("unregistered global name",
+		"pid", pid.Id,
+		"name", name)
+
+	broadcastGlobalName(remoteGlobalNameTuple{Name: name, Pid: pid, Unregister: true})
+}
+
+func unregisterGlobalNames(pid *Pid) {
+	globalNamesMu.Lock()
+
+	unregistered := make([]string, 0)
+
+	for _, name := range pid.globalNames {
+		//the name might have been lost in a conflict
+		if p, ok := globalNames[name]; ok && p.Is(pid) {
+			delete(globalNames, name)
+			unregistered = append(unregistered, name)
+		}
+	}
+
+	pid.globalNames = nil
+
+	globalNamesMu.Unlock()
+
+	for _, name := range unregistered {
+		broadcastGlobalName(remoteGlobalNameTuple{Name: name, Pid: pid, Unregister: true})
+	}
+}
+
+//broadcastGlobalName (just like syncGlobalNames) must not hold
+//any lock while sending because globalNameChan can fill up if a
+//connection hangs, which would block every RegisterGlobal
+func broadcastGlobalName(t remoteGlobalNameTuple) {
+	for _, m := range getMachines() {
+		if m.isConnected() {
+			m.globalNameChan <- t
+		}
+	}
+}
+
+//syncGlobalNames sends all global names that are owned by
+//actors on the local machine to a newly connected machine
+func syncGlobalNames(m *Machine) {
+	globalNamesMu.RLock()
+
+	owned := make([]remoteGlobalNameTuple, 0)
+	for name, pid := range globalNames {
+		if pid.MachineId == machineId {
+			owned = append(owned, remoteGlobalNameTuple{Name: name, Pid: pid})
+		}
+	}
+
+	globalNamesMu.RUnlock()
+
+	for _, t := range owned {
+		m.globalNameChan <- t
+	}
+}
+
+func handleRemoteGlobalName(t remoteGlobalNameTuple) {
+	globalNamesMu.Lock()
+	defer globalNamesMu.Unlock()
+
+	existing, ok := globalNames[t.Name]
+
+	if t.Unregister {
+		if ok && existing.Is(t.Pid) {
+			delete(globalNames, t.Name)
+		}
+
+		return
+	}
+
+	if !ok || existing.Is(t.Pid) {
+		globalNames[t.Name] = t.Pid
+		return
+	}
+
+	winner := conflictPolicy(t.Name, existing, t.Pid)
+
+	logger.Warn("resolved global name conflict",
+		"name", t.Name,
+		"existing_gpid", existing.String(),
+		"incoming_gpid", t.Pid.String(),
+		"winner_gpid", winner.String())
+
+	globalNames[t.Name] = winner
+}
+
+//removeGlobalNamesOfMachine removes all global names that are
+//owned by actors on a machine that disconnected
+func removeGlobalNamesOfMachine(id string) {
+	globalNamesMu.Lock()
+	defer globalNamesMu.Unlock()
+
+	for name, pid := range globalNames {
+		if pid.MachineId == id {
+			delete(globalNames, name)
+		}
+	}
+}
@@ -6,25 +6,53 @@ import (
 	"github.com/Azer0s/quacktors/config"
 	"github.com/Azer0s/quacktors/logging"
 	"github.com/Azer0s/quacktors/typeregister"
-	"github.com/vmihailenco/msgpack/v5"
 	"net"
+	"time"
 )
 
 var messageGatewayPort = uint16(0)
 var gpGatewayPort = uint16(0)
 
+var messageGatewayListener net.Listener
+var gpGatewayListener net.Listener
+
 var logger logging.Logger
 var qpmdPort uint16
+var maxFrameSize uint32
+var childShutdownTimeout time.Duration
+var cookie string
 
 func initQuacktorSystems() {
 	logger = config.GetLogger()
 	qpmdPort = config.GetQpmdPort()
+	maxFrameSize = config.GetMaxFrameSize()
+	childShutdownTimeout = config.GetChildShutdownTimeout()
+	cookie = config.GetCookie()
+	setReconnectPolicy(config.GetReconnectPolicy())
+
+	initializeTLS()
 
 	initializeGateways()
 	initializeQpmdConnection()
 	initializeBuiltInMessages()
 }
 
+func initializeTLS() {
+	c := config.GetTLSConfig()
+
+	if c == nil {
+		return
+	}
+
+	server, client, err := loadTLSConfig(c)
+	if err != nil {
+		logger.Fatal("there was an error while loading the TLS config",
+			"error", err)
+	}
+
+	setTLSConfig(server, client)
+}
+
 func initializeGateways() {
 	var err error
 
@@ -51,7 +79,7 @@ func initializeQpmdConnection() {
 	conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", qpmdPort))
 	failIfConnectionError(err)
 
-	b, err := msgpack.Marshal(qpmd.Request{
+	err = sendQpmdRequest(conn, qpmd.Request{
 		RequestType: qpmd.REQUEST_HELLO,
 		Data: map[string]interface{}{
 			qpmd.MACHINE_ID:           machineId,
@@ -59,25 +87,21 @@ func initializeQpmdConnection() {
 			qpmd.GP_GATEWAY_PORT:      gpGatewayPort,
 		},
 	})
-	try(err)
-
-	_, err = conn.Write(b)
 	failIfConnectionError(err)
 
-	buf := make([]byte, 4096)
-	_, err = conn.Read(buf)
+	_, err = readQpmdResponse(conn)
 	failIfConnectionError(err)
-
-	res := qpmd.Response{}
-	err = msgpack.Unmarshal(buf, &res)
-	try(err)
 }
 
 func initializeBuiltInMessages() {
 	typeregister.Store(Pid{}.Type(), Pid{})
 	typeregister.Store(DownMessage{}.Type(), DownMessage{})
+	typeregister.Store(ExitMessage{}.Type(), ExitMessage{})
 	typeregister.Store(PoisonPill{}.Type(), PoisonPill{})
+	typeregister.Store(FutureResultMessage{}.Type(), FutureResultMessage{})
 	typeregister.Store(GenericMessage{}.Type(), GenericMessage{})
 	typeregister.Store(DisconnectMessage{}.Type(), DisconnectMessage{})
+	typeregister.Store(MachineReconnectedMessage{}.Type(), MachineReconnectedMessage{})
 	typeregister.Store(KillMessage{}.Type(), KillMessage{})
+	typeregister.Store(ReceiveTimeoutMessage{}.Type(), ReceiveTimeoutMessage{})
 }
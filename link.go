@@ -0,0 +1,201 @@
+package quacktors
+
+//Links are bidirectional. Both sides keep the other one in their
+//links map (which is only ever touched by the actor goroutine itself)
+//and send each other exit signals when they go down.
+//All link, unlink and exit requests are sent asynchronously so two
+//actors that link to (or exit) each other at the same time can't
+//deadlock.
+
+func sendLinkRequest(from *Pid, to *Pid) {
+	go pushLinkRequest(from, to)
+}
+
+//pushLinkRequest blocks until the actor got the link request (or went down)
+func pushLinkRequest(from *Pid, to *Pid) {
+	if to.MachineId != machineId {
+		logger.Debug("pid to link is not on this machine, forwarding to remote machine",
+			"linked_gpid", to.String(),
+			"link_gpid", from.String(),
+			"machine_id", to.MachineId)
+
+		m, ok := getMachine(to.MachineId)
+
+		if ok && m.isConnected() {
+			m.linkChan <- remoteLinkTuple{From: from, To: to}
+		}
+
+		//if the machine isn't connected, addLink already sent out an exit signal
+		return
+	}
+
+	if p := dispatchedPid(to); p != nil {
+		if !p.pushSignal(linkSignal{from: from}) {
+			sendExitSignal(to, from, ExitReason{Kind: NO_PROC_EXIT})
+		}
+
+		return
+	}
+
+	//the pid might have been sent to a remote machine and back
+	p := spawnedPid(to)
+
+	if p == nil {
+		sendExitSignal(to, from, ExitReason{Kind: NO_PROC_EXIT})
+		return
+	}
+
+	select {
+	case p.linkChan <- from:
+	case <-p.done:
+		//the actor went down before it got the request
+		sendExitSignal(to, from, ExitReason{Kind: NO_PROC_EXIT})
+	}
+}
+
+func sendUnlinkRequest(from *Pid, to *Pid) {
+	go pushUnlinkRequest(from, to)
+}
+
+//pushUnlinkRequest blocks until the actor got the unlink request (or went down)
+func pushUnlinkRequest(from *Pid, to *Pid) {
+	if to.MachineId != machineId {
+		m, ok := getMachine(to.MachineId)
+
+		if ok && m.isConnected() {
+			m.unlinkChan <- remoteLinkTuple{From: from, To: to}
+		}
+
+		return
+	}
+
+	if p := dispatchedPid(to); p != nil {
+		p.pushSignal(unlinkSignal{from: from})
+		return
+	}
+
+	p := spawnedPid(to)
+
+	if p == nil {
+		return
+	}
+
+	select {
+	case p.unlinkChan <- from:
+	case <-p.done:
+	}
+}
+
+func sendExitSignal(from *Pid, to *Pid, reason ExitReason) {
+	go pushExitSignal(from, to, reason)
+}
+
+//pushExitSignal blocks until the actor got the exit signal (or went down)
+func pushExitSignal(from *Pid, to *Pid, reason ExitReason) {
+	if to.MachineId != machineId {
+		m, ok := getMachine(to.MachineId)
+
+		if ok && m.isConnected() {
+			m.exitChan <- remoteExitTuple{From: from, To: to, Reason: reason}
+		}
+
+		return
+	}
+
+	if p := dispatchedPid(to); p != nil {
+		p.pushSignal(ExitMessage{Who: from, Reason: reason})
+		return
+	}
+
+	p := spawnedPid(to)
+
+	if p == nil {
+		return
+	}
+
+	select {
+	case p.exitChan <- ExitMessage{Who: from, Reason: reason}:
+	case <-p.done:
+	}
+}
+
+func handleLinkRequest(pid *Pid, from *Pid) {
+	logger.Info("actor received link request",
+		"pid", pid.Id,
+		"link_gpid", from.String())
+
+	if from.MachineId == machineId {
+		if _, ok := getByPidId(from.Id); !ok {
+			//the linking actor went down before we got its request
+			//its exit signal was ignored because we weren't linked yet
+			logger.Debug("linking actor is already dead, ignoring link request",
+				"pid", pid.Id,
+				"link_gpid", from.String())
+			return
+		}
+	}
+
+	pid.addLink(from)
+}
+
+func handleUnlinkRequest(pid *Pid, from *Pid) {
+	logger.Info("actor received unlink request",
+		"pid", pid.Id,
+		"link_gpid", from.String())
+
+	pid.removeLink(from)
+}
+
+func (pid *Pid) isLinked(other *Pid) bool {
+	_, ok := pid.links[other.String()]
+	return ok
+}
+
+func (pid *Pid) addLink(other *Pid) {
+	name := other.String()
+
+	if _, ok := pid.links[name]; ok {
+		return
+	}
+
+	pid.links[name] = other
+
+	if other.MachineId == machineId {
+		return
+	}
+
+	//if the connection to the remote machine goes down, the link breaks
+	m, ok := getMachine(other.MachineId)
+
+	if ok && m.isConnected() {
+		m.setupRemoteLink(pid, other)
+		return
+	}
+
+	logger.Warn("remote machine of linked pid is not connected, sending out exit signal immediately",
+		"pid", pid.Id,
+		"link_gpid", other.String(),
+		"machine_id", other.MachineId)
+
+	sendExitSignal(other, pid, ExitReason{Kind: NO_CONNECTION_EXIT})
+}
+
+func (pid *Pid) removeLink(other *Pid) {
+	name := other.String()
+
+	if _, ok := pid.links[name]; !ok {
+		return
+	}
+
+	delete(pid.links, name)
+
+	if other.MachineId == machineId {
+		return
+	}
+
+	m, ok := getMachine(other.MachineId)
+
+	if ok {
+		m.removeRemoteLink(pid, other)
+	}
+}
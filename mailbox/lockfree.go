@@ -0,0 +1,232 @@
+package mailbox
+
+import (
+	"container/list"
+	"go.uber.org/atomic"
+)
+
+//LockFree creates a Mailbox that is backed by lock-free
+//multi-producer single-consumer queues instead of a goroutine
+//and two channels. Pushing never takes a lock (unless a bounded
+//Mailbox with the BLOCK_POLICY is full). The consumer pops
+//elements (see Pop) and parks on the channel returned by Wait
+//while the Mailbox is empty. LockFree can be combined with all
+//other options (including Scheduled).
+func LockFree() Option {
+	return func(mb *Mailbox) {
+		mb.lockFree = true
+	}
+}
+
+//readyNow is returned by Wait if there already is an element to pop
+var readyNow = func() chan bool {
+	c := make(chan bool)
+	close(c)
+	return c
+}()
+
+func (mb *Mailbox) initLockFree() {
+	mb.normalLane = newMpscQueue()
+	mb.systemLane = newMpscQueue()
+
+	if mb.isPriority != nil {
+		mb.priorityLane = newMpscQueue()
+	}
+
+	mb.frontNormal = list.New()
+	mb.frontPriority = list.New()
+
+	mb.length = atomic.NewInt64(0)
+	mb.priorityLength = atomic.NewInt64(0)
+	mb.excess = atomic.NewInt64(0)
+	mb.blocked = atomic.NewInt64(0)
+	mb.parked = atomic.NewBool(false)
+	mb.closedFlag = atomic.NewBool(false)
+}
+
+func (mb *Mailbox) pushLockFree(elem interface{}) error {
+	if mb.closedFlag.Load() {
+		panic(ErrClosed)
+	}
+
+	if mb.priorityLane != nil && mb.isPriority(elem) {
+		mb.priorityLength.Inc()
+		mb.priorityLane.push(elem)
+		mb.wake()
+
+		return nil
+	}
+
+	if mb.capacity > 0 {
+		switch mb.policy {
+		case DROP_OLDEST_POLICY:
+			//only the consumer can take elements out of the queue,
+			//so it skips over the oldest ones on the next pop
+			if mb.length.Inc()-mb.excess.Load() > int64(mb.capacity) {
+				mb.excess.Inc()
+				mb.drop(1)
+			}
+		case BLOCK_POLICY:
+			mb.reserveBlocking()
+		default:
+			if !mb.reserve() {
+				mb.drop(1)
+
+				if mb.policy == FAIL_POLICY {
+					return ErrFull
+				}
+
+				return nil
+			}
+		}
+	} else {
+		mb.length.Inc()
+	}
+
+	mb.normalLane.push(elem)
+	mb.wake()
+
+	return nil
+}
+
+//reserve takes up a slot in a bounded mailbox and returns false if it is full
+func (mb *Mailbox) reserve() bool {
+	for {
+		n := mb.length.Load()
+
+		if n >= int64(mb.capacity) {
+			return false
+		}
+
+		if mb.length.CAS(n, n+1) {
+			return true
+		}
+	}
+}
+
+func (mb *Mailbox) reserveBlocking() {
+	if mb.reserve() {
+		return
+	}
+
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	mb.blocked.Inc()
+	defer mb.blocked.Dec()
+
+	for !mb.reserve() {
+		if mb.closedFlag.Load() {
+			panic(ErrClosed)
+		}
+
+		mb.notFull.Wait()
+	}
+
+	if mb.closedFlag.Load() {
+		panic(ErrClosed)
+	}
+}
+
+//freed wakes up a sender that is blocked on a full mailbox
+func (mb *Mailbox) freed() {
+	if mb.blocked.Load() > 0 {
+		mb.mu.Lock()
+		mb.notFull.Signal()
+		mb.mu.Unlock()
+	}
+}
+
+func (mb *Mailbox) pushSystemLockFree(elem interface{}) {
+	if mb.closedFlag.Load() {
+		panic(ErrClosed)
+	}
+
+	mb.systemLane.push(elem)
+	mb.wake()
+}
+
+func (mb *Mailbox) pushFrontLockFree(elems []interface{}) {
+	for i := len(elems) - 1; i >= 0; i-- {
+		if mb.priorityLane != nil && mb.isPriority(elems[i]) {
+			mb.priorityLength.Inc()
+			mb.frontPriority.PushFront(elems[i])
+			continue
+		}
+
+		mb.length.Inc()
+		mb.frontNormal.PushFront(elems[i])
+	}
+}
+
+//wake tells the consumer that there is a new element
+func (mb *Mailbox) wake() {
+	if mb.schedule != nil {
+		mb.schedule()
+	}
+
+	if mb.parked.CAS(true, false) {
+		select {
+		case mb.readyChan <- true:
+		default:
+		}
+	}
+}
+
+func (mb *Mailbox) popLockFree() (interface{}, bool) {
+	if elem, ok := mb.systemLane.pop(); ok {
+		return elem, true
+	}
+
+	if mb.frontPriority.Len() != 0 {
+		mb.priorityLength.Dec()
+		return mb.frontPriority.Remove(mb.frontPriority.Front()), true
+	}
+
+	if mb.priorityLane != nil {
+		if elem, ok := mb.priorityLane.pop(); ok {
+			mb.priorityLength.Dec()
+			return elem, true
+		}
+	}
+
+	if mb.frontNormal.Len() != 0 {
+		mb.length.Dec()
+		mb.freed()
+		return mb.frontNormal.Remove(mb.frontNormal.Front()), true
+	}
+
+	for {
+		elem, ok := mb.normalLane.pop()
+
+		if !ok {
+			return nil, false
+		}
+
+		mb.length.Dec()
+
+		if mb.excess.Load() > 0 {
+			//the element was already recorded as dropped (see DROP_OLDEST_POLICY)
+			mb.excess.Dec()
+			continue
+		}
+
+		mb.freed()
+
+		return elem, true
+	}
+}
+
+func (mb *Mailbox) pendingLockFree() bool {
+	return !mb.systemLane.empty() || mb.frontPriority.Len() != 0 || mb.frontNormal.Len() != 0 ||
+		(mb.priorityLane != nil && !mb.priorityLane.empty()) || !mb.normalLane.empty()
+}
+
+func (mb *Mailbox) closeLockFree() {
+	mb.closedFlag.Store(true)
+
+	//senders that are blocked on a full mailbox panic
+	mb.mu.Lock()
+	mb.notFull.Broadcast()
+	mb.mu.Unlock()
+}
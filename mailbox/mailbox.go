@@ -2,45 +2,357 @@ package mailbox
 
 import (
 	"container/list"
+	"errors"
+	"go.uber.org/atomic"
+	"sync"
 )
 
+//ErrFull is returned by Push if a bounded Mailbox with
+//the FAIL_POLICY has reached its capacity.
+var ErrFull = errors.New("mailbox is full")
+
+//ErrClosed is what pushing to a closed scheduled Mailbox
+//panics with (see Scheduled).
+var ErrClosed = errors.New("mailbox is closed")
+
+//OverflowPolicy defines what a bounded Mailbox does
+//with a new element when it has reached its capacity.
+type OverflowPolicy int
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	//The DROP_NEWEST_POLICY indicates that a full Mailbox
+	//should drop the element that was just pushed.
+	DROP_NEWEST_POLICY OverflowPolicy = iota
+
+	//The DROP_OLDEST_POLICY indicates that a full Mailbox
+	//should drop its oldest element to make room for the
+	//element that was just pushed.
+	DROP_OLDEST_POLICY
+
+	//The BLOCK_POLICY indicates that a full Mailbox should
+	//block the sender until there is room again.
+	BLOCK_POLICY
+
+	//The FAIL_POLICY indicates that a full Mailbox should
+	//reject the element that was just pushed and let Push
+	//return ErrFull.
+	FAIL_POLICY
+)
+
+//An Option configures a Mailbox on creation.
+type Option func(mb *Mailbox)
+
+//Bounded limits the Mailbox buffer to capacity elements.
+//The policy defines what happens when the buffer is full.
+func Bounded(capacity int, policy OverflowPolicy) Option {
+	return func(mb *Mailbox) {
+		mb.capacity = capacity
+		mb.policy = policy
+	}
+}
+
+//OnDrop sets a callback that is called whenever a bounded
+//Mailbox drops or rejects elements because it is full.
+func OnDrop(callback func(amount int)) Option {
+	return func(mb *Mailbox) {
+		mb.onDrop = callback
+	}
+}
+
+//Priority splits the Mailbox into a priority lane and a normal
+//lane. Elements for which isPriority returns true skip ahead of
+//all elements in the normal lane. The capacity of a bounded
+//Mailbox only applies to the normal lane, elements in the
+//priority lane are never dropped or rejected.
+func Priority(isPriority func(elem interface{}) bool) Option {
+	return func(mb *Mailbox) {
+		mb.isPriority = isPriority
+		mb.priorityQueue = list.New()
+	}
+}
+
+//Scheduled creates a Mailbox that doesn't run a goroutine of
+//its own. Instead of reading from Out, the owner of the Mailbox
+//pops elements (see Pop) after schedule was called. schedule is
+//called after every push, so it has to be cheap and must not
+//block.
+func Scheduled(schedule func()) Option {
+	return func(mb *Mailbox) {
+		mb.schedule = schedule
+	}
+}
+
+type pushRequest struct {
+	elem   interface{}
+	result chan error
+}
+
 //New creates a new Mailbox and returns the pointer to it.
-func New() *Mailbox {
+//Without any options, the Mailbox is unbounded.
+func New(options ...Option) *Mailbox {
 	mb := &Mailbox{
-		inChan:  make(chan interface{}),
-		outChan: make(chan interface{}),
-		queue:   list.New(),
+		queue: list.New(),
+	}
+
+	for _, option := range options {
+		option(mb)
+	}
+
+	if mb.schedule != nil || mb.lockFree {
+		mb.mu = &sync.Mutex{}
+		mb.notFull = sync.NewCond(mb.mu)
+		mb.systemQueue = list.New()
+		mb.readyChan = make(chan bool, 1)
+
+		if mb.lockFree {
+			mb.initLockFree()
+		}
+
+		return mb
 	}
 
+	mb.inChan = make(chan interface{})
+	if mb.priorityQueue != nil {
+		mb.priorityChan = make(chan interface{})
+	}
+	mb.outChan = make(chan interface{})
+	mb.frontChan = make(chan []interface{})
+	mb.closeChan = make(chan bool)
+	mb.count = atomic.NewInt64(0)
+
 	mb.start()
 
 	return mb
 }
 
 type Mailbox struct {
-	inChan  chan interface{}
-	outChan chan interface{}
-	queue   *list.List
+	inChan    chan interface{}
+	outChan   chan interface{}
+	frontChan chan []interface{}
+	closeChan chan bool
+	queue     *list.List
+	capacity  int
+	policy    OverflowPolicy
+	onDrop    func(amount int)
+	//priorityQueue is only set if the mailbox was created with the Priority option
+	priorityQueue *list.List
+	isPriority    func(elem interface{}) bool
+	//priorityChan is always read from (even if the normal lane is full and blocks)
+	priorityChan chan interface{}
+	//count is the length of the buffer (the buffer itself is only touched by the mailbox goroutine)
+	count *atomic.Int64
+	//the following fields are only set if the mailbox was created with the Scheduled option
+	schedule    func()
+	mu          *sync.Mutex
+	notFull     *sync.Cond
+	systemQueue *list.List
+	readyChan   chan bool
+	closed      bool
+	//the following fields are only set if the mailbox was created with the LockFree option
+	lockFree       bool
+	normalLane     *mpscQueue
+	priorityLane   *mpscQueue
+	systemLane     *mpscQueue
+	frontNormal    *list.List
+	frontPriority  *list.List
+	length         *atomic.Int64
+	priorityLength *atomic.Int64
+	excess         *atomic.Int64
+	blocked        *atomic.Int64
+	parked         *atomic.Bool
+	closedFlag     *atomic.Bool
 }
 
-//In returns the input channel of a mailbox.
+//In returns the input channel of a mailbox
+//(nil for a scheduled or lock-free mailbox).
 func (mb *Mailbox) In() chan<- interface{} {
 	return mb.inChan
 }
 
-//Out returns the output channel of a mailbox.
+//Out returns the output channel of a mailbox
+//(nil for a scheduled or lock-free mailbox, see Pop).
 func (mb *Mailbox) Out() <-chan interface{} {
 	return mb.outChan
 }
 
-//Len returns the length of the mailbox buffer.
+//Push pushes an element into the mailbox. Push only returns
+//an error (ErrFull) if the mailbox is bounded with the
+//FAIL_POLICY and has reached its capacity. Pushing to a
+//closed mailbox panics.
+func (mb *Mailbox) Push(elem interface{}) error {
+	if mb.lockFree {
+		return mb.pushLockFree(elem)
+	}
+
+	if mb.schedule != nil {
+		return mb.pushScheduled(elem)
+	}
+
+	if mb.priorityChan != nil && mb.isPriority(elem) {
+		//priority elements don't queue up behind a full normal lane
+		mb.priorityChan <- elem
+		return nil
+	}
+
+	if mb.capacity <= 0 || mb.policy != FAIL_POLICY {
+		mb.inChan <- elem
+		return nil
+	}
+
+	result := make(chan error, 1)
+	mb.inChan <- pushRequest{
+		elem:   elem,
+		result: result,
+	}
+
+	return <-result
+}
+
+//PushFront puts elements back to the front of the mailbox
+//buffer so they are read before any other element. The
+//elements keep the order they were provided in. PushFront
+//ignores the capacity of a bounded mailbox. PushFront may
+//only be called by the consumer of a lock-free mailbox.
+func (mb *Mailbox) PushFront(elems ...interface{}) {
+	if mb.lockFree {
+		mb.pushFrontLockFree(elems)
+		return
+	}
+
+	if mb.schedule != nil {
+		mb.pushFrontScheduled(elems)
+		return
+	}
+
+	mb.frontChan <- elems
+}
+
+//Len returns the length of the mailbox buffer
+//(including the priority lane).
 func (mb *Mailbox) Len() int {
+	if mb.lockFree {
+		return int(mb.length.Load() - mb.excess.Load() + mb.priorityLength.Load())
+	}
+
+	if mb.schedule == nil {
+		return int(mb.count.Load())
+	}
+
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	return mb.bufferLen()
+}
+
+func (mb *Mailbox) bufferLen() int {
+	if mb.priorityQueue != nil {
+		return mb.queue.Len() + mb.priorityQueue.Len()
+	}
+
 	return mb.queue.Len()
 }
 
+//Close closes the mailbox. Senders that are blocked on a
+//full mailbox (see BLOCK_POLICY) panic, just as they would
+//when sending to a closed channel.
+func (mb *Mailbox) Close() {
+	if mb.lockFree {
+		mb.closeLockFree()
+		return
+	}
+
+	if mb.schedule != nil {
+		mb.closeScheduled()
+		return
+	}
+
+	close(mb.inChan)
+	if mb.priorityChan != nil {
+		close(mb.priorityChan)
+	}
+	close(mb.closeChan)
+}
+
+func (mb *Mailbox) full() bool {
+	return mb.capacity > 0 && mb.queue.Len() >= mb.capacity
+}
+
+func (mb *Mailbox) drop(amount int) {
+	if mb.onDrop != nil {
+		mb.onDrop(amount)
+	}
+}
+
+func (mb *Mailbox) lane(elem interface{}) *list.List {
+	if mb.priorityQueue != nil && mb.isPriority(elem) {
+		return mb.priorityQueue
+	}
+
+	return mb.queue
+}
+
+func (mb *Mailbox) push(elem interface{}) {
+	if req, ok := elem.(pushRequest); ok {
+		if lane := mb.lane(req.elem); lane != mb.queue {
+			lane.PushBack(req.elem)
+			req.result <- nil
+			return
+		}
+
+		if mb.full() {
+			mb.drop(1)
+			req.result <- ErrFull
+			return
+		}
+
+		mb.queue.PushBack(req.elem)
+		req.result <- nil
+		return
+	}
+
+	if lane := mb.lane(elem); lane != mb.queue {
+		lane.PushBack(elem)
+		return
+	}
+
+	if !mb.full() {
+		mb.queue.PushBack(elem)
+		return
+	}
+
+	switch mb.policy {
+	case DROP_OLDEST_POLICY:
+		mb.queue.Remove(mb.queue.Front())
+		mb.queue.PushBack(elem)
+		mb.drop(1)
+	default:
+		//this also covers the FAIL_POLICY if someone wrote to In directly
+		//(we have no way of telling them, so we just drop the element)
+		mb.drop(1)
+	}
+}
+
 func (mb *Mailbox) start() {
+	getInCh := func() <-chan interface{} {
+		if mb.policy == BLOCK_POLICY && mb.full() {
+			return nil
+		}
+
+		return mb.inChan
+	}
+
+	//the priority lane is always drained first
+	getCurLane := func() *list.List {
+		if mb.priorityQueue != nil && mb.priorityQueue.Len() != 0 {
+			return mb.priorityQueue
+		}
+
+		return mb.queue
+	}
+
 	getOutCh := func() chan<- interface{} {
-		if mb.queue.Len() == 0 {
+		if getCurLane().Len() == 0 {
 			return nil
 		}
 
@@ -48,26 +360,45 @@ func (mb *Mailbox) start() {
 	}
 
 	getCurVal := func() interface{} {
-		if mb.queue.Len() == 0 {
+		if getCurLane().Len() == 0 {
 			return nil
 		}
 
-		return mb.queue.Front().Value
+		return getCurLane().Front().Value
 	}
 
 	go func() {
 		for {
 			select {
-			case elem, ok := <-mb.inChan:
+			case elem, ok := <-getInCh():
 				if ok {
-					mb.queue.PushBack(elem)
+					mb.push(elem)
 				} else {
 					close(mb.outChan)
 					return
 				}
+			case elem, ok := <-mb.priorityChan:
+				if ok {
+					mb.priorityQueue.PushBack(elem)
+				} else {
+					close(mb.outChan)
+					return
+				}
+			case elems := <-mb.frontChan:
+				for i := len(elems) - 1; i >= 0; i-- {
+					mb.lane(elems[i]).PushFront(elems[i])
+				}
+			case <-mb.closeChan:
+				//a full mailbox that blocks doesn't read from inChan,
+				//so it would never notice that inChan was closed
+				close(mb.outChan)
+				return
 			case getOutCh() <- getCurVal():
-				mb.queue.Remove(mb.queue.Front())
+				lane := getCurLane()
+				lane.Remove(lane.Front())
 			}
+
+			mb.count.Store(int64(mb.bufferLen()))
 		}
 	}()
 }
@@ -52,3 +52,442 @@ func TestMailboxClose(t *testing.T) {
 	_, ok := <-out
 	assert.False(t, ok)
 }
+
+func TestMailboxPushFront(t *testing.T) {
+	mb := New()
+
+	in := mb.In()
+	out := mb.Out()
+
+	in <- 3
+	in <- 4
+
+	mb.PushFront(1, 2)
+
+	for i := 1; i <= 4; i++ {
+		assert.Equal(t, i, <-out)
+	}
+}
+
+func TestMailboxDropNewest(t *testing.T) {
+	dropped := make(chan int, 1)
+
+	mb := New(Bounded(2, DROP_NEWEST_POLICY), OnDrop(func(amount int) {
+		dropped <- amount
+	}))
+
+	_ = mb.Push(1)
+	_ = mb.Push(2)
+	_ = mb.Push(3)
+
+	assert.Equal(t, 1, <-dropped)
+	assert.Equal(t, 1, <-mb.Out())
+	assert.Equal(t, 2, <-mb.Out())
+}
+
+func TestMailboxDropOldest(t *testing.T) {
+	dropped := make(chan int, 1)
+
+	mb := New(Bounded(2, DROP_OLDEST_POLICY), OnDrop(func(amount int) {
+		dropped <- amount
+	}))
+
+	_ = mb.Push(1)
+	_ = mb.Push(2)
+	_ = mb.Push(3)
+
+	assert.Equal(t, 1, <-dropped)
+	assert.Equal(t, 2, <-mb.Out())
+	assert.Equal(t, 3, <-mb.Out())
+}
+
+func TestMailboxFail(t *testing.T) {
+	mb := New(Bounded(1, FAIL_POLICY))
+
+	assert.NoError(t, mb.Push(1))
+	assert.Equal(t, ErrFull, mb.Push(2))
+	assert.Equal(t, 1, <-mb.Out())
+	assert.NoError(t, mb.Push(3))
+}
+
+func TestMailboxBlock(t *testing.T) {
+	mb := New(Bounded(1, BLOCK_POLICY))
+
+	_ = mb.Push(1)
+
+	pushed := make(chan bool)
+	go func() {
+		_ = mb.Push(2)
+		pushed <- true
+	}()
+
+	select {
+	case <-pushed:
+		t.Fail()
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	assert.Equal(t, 1, <-mb.Out())
+	<-pushed
+	assert.Equal(t, 2, <-mb.Out())
+}
+
+func TestMailboxCloseWhileBlocked(t *testing.T) {
+	mb := New(Bounded(1, BLOCK_POLICY))
+
+	_ = mb.Push(1)
+	mb.Close()
+
+	<-time.After(10 * time.Millisecond)
+
+	_, ok := <-mb.Out()
+	assert.False(t, ok)
+}
+
+func TestMailboxPriority(t *testing.T) {
+	mb := New(Priority(func(elem interface{}) bool {
+		return elem == "priority"
+	}))
+
+	_ = mb.Push("normal")
+	_ = mb.Push("priority")
+
+	<-time.After(10 * time.Millisecond)
+
+	assert.Equal(t, 2, mb.Len())
+	assert.Equal(t, "priority", <-mb.Out())
+	assert.Equal(t, "normal", <-mb.Out())
+}
+
+func TestMailboxPriorityBlock(t *testing.T) {
+	mb := New(Bounded(1, BLOCK_POLICY), Priority(func(elem interface{}) bool {
+		return elem == "priority"
+	}))
+
+	_ = mb.Push("normal")
+
+	blocked := make(chan bool)
+	go func() {
+		_ = mb.Push("blocked")
+		blocked <- true
+	}()
+
+	//the normal lane is full but the priority lane still accepts elements
+	pushed := make(chan bool)
+	go func() {
+		_ = mb.Push("priority")
+		pushed <- true
+	}()
+
+	select {
+	case <-pushed:
+	case <-time.After(time.Second):
+		t.Fatal("priority push was blocked by the full normal lane")
+	}
+
+	assert.Equal(t, "priority", <-mb.Out())
+	assert.Equal(t, "normal", <-mb.Out())
+	<-blocked
+	assert.Equal(t, "blocked", <-mb.Out())
+}
+
+func TestMailboxScheduled(t *testing.T) {
+	scheduled := 0
+
+	mb := New(Scheduled(func() {
+		scheduled++
+	}), Priority(func(elem interface{}) bool {
+		return elem == "priority"
+	}))
+
+	assert.Nil(t, mb.Out())
+	assert.False(t, mb.Pending())
+
+	_ = mb.Push("normal")
+	_ = mb.Push("priority")
+	mb.PushSystem("system")
+
+	assert.Equal(t, 3, scheduled)
+	assert.Equal(t, 2, mb.Len())
+	assert.True(t, mb.Pending())
+
+	select {
+	case <-mb.Wait():
+	default:
+		t.Fail()
+	}
+
+	//the system lane goes first, then the priority lane
+	for _, expected := range []string{"system", "priority", "normal"} {
+		elem, ok := mb.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, expected, elem)
+	}
+
+	_, ok := mb.Pop()
+	assert.False(t, ok)
+
+	mb.PushFront("front")
+	elem, _ := mb.Pop()
+	assert.Equal(t, "front", elem)
+}
+
+func TestMailboxScheduledBounded(t *testing.T) {
+	dropped := 0
+
+	mb := New(Scheduled(func() {}), Bounded(1, FAIL_POLICY), OnDrop(func(amount int) {
+		dropped += amount
+	}))
+
+	assert.NoError(t, mb.Push(1))
+	assert.Equal(t, ErrFull, mb.Push(2))
+	assert.Equal(t, 1, dropped)
+
+	//the system lane ignores the capacity
+	mb.PushSystem(3)
+	assert.Equal(t, 1, mb.Len())
+}
+
+func TestMailboxScheduledBlock(t *testing.T) {
+	mb := New(Scheduled(func() {}), Bounded(1, BLOCK_POLICY))
+
+	_ = mb.Push(1)
+
+	pushed := make(chan bool)
+
+	go func() {
+		_ = mb.Push(2)
+		pushed <- true
+	}()
+
+	select {
+	case <-pushed:
+		t.Fail()
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	elem, _ := mb.Pop()
+	assert.Equal(t, 1, elem)
+	<-pushed
+
+	elem, _ = mb.Pop()
+	assert.Equal(t, 2, elem)
+
+	mb.Close()
+	assert.PanicsWithValue(t, ErrClosed, func() {
+		_ = mb.Push(3)
+	})
+}
+
+func TestMailboxLockFree(t *testing.T) {
+	mb := New(LockFree(), Priority(func(elem interface{}) bool {
+		return elem == "priority"
+	}))
+
+	assert.Nil(t, mb.Out())
+
+	_ = mb.Push("normal")
+	_ = mb.Push("priority")
+	mb.PushSystem("system")
+
+	assert.Equal(t, 2, mb.Len())
+
+	//the system lane goes first, then the priority lane
+	for _, expected := range []string{"system", "priority", "normal"} {
+		elem, ok := mb.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, expected, elem)
+	}
+
+	_, ok := mb.Pop()
+	assert.False(t, ok)
+	assert.Equal(t, 0, mb.Len())
+
+	_ = mb.Push("back")
+	mb.PushFront("front", "priority")
+	assert.Equal(t, 3, mb.Len())
+
+	for _, expected := range []string{"priority", "front", "back"} {
+		elem, _ := mb.Pop()
+		assert.Equal(t, expected, elem)
+	}
+}
+
+func TestMailboxLockFreeConcurrent(t *testing.T) {
+	mb := New(LockFree())
+
+	const producers = 8
+	const elems = 10_000
+
+	for p := 0; p < producers; p++ {
+		go func(p int) {
+			for i := 0; i < elems; i++ {
+				_ = mb.Push([2]int{p, i})
+			}
+		}(p)
+	}
+
+	next := make([]int, producers)
+
+	for c := 0; c < producers*elems; {
+		elem, ok := mb.Pop()
+
+		if !ok {
+			<-mb.Wait()
+			continue
+		}
+
+		//elements of one producer keep their order
+		e := elem.([2]int)
+		assert.Equal(t, next[e[0]], e[1])
+		next[e[0]]++
+		c++
+	}
+
+	assert.Equal(t, 0, mb.Len())
+}
+
+func TestMailboxLockFreeWait(t *testing.T) {
+	mb := New(LockFree())
+
+	wait := mb.Wait()
+
+	select {
+	case <-wait:
+		t.Fail()
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	_ = mb.Push(1)
+
+	select {
+	case <-wait:
+	case <-time.After(1 * time.Second):
+		t.Fail()
+	}
+
+	//there is something to pop, so Wait doesn't park
+	<-mb.Wait()
+}
+
+func TestMailboxLockFreeBounded(t *testing.T) {
+	dropped := 0
+	onDrop := OnDrop(func(amount int) {
+		dropped += amount
+	})
+
+	mb := New(LockFree(), Bounded(1, FAIL_POLICY), onDrop)
+	assert.NoError(t, mb.Push(1))
+	assert.Equal(t, ErrFull, mb.Push(2))
+	assert.Equal(t, 1, mb.Len())
+	assert.Equal(t, 1, dropped)
+
+	mb = New(LockFree(), Bounded(1, DROP_NEWEST_POLICY), onDrop)
+	_ = mb.Push(1)
+	_ = mb.Push(2)
+	elem, _ := mb.Pop()
+	assert.Equal(t, 1, elem)
+	assert.Equal(t, 2, dropped)
+
+	mb = New(LockFree(), Bounded(2, DROP_OLDEST_POLICY), onDrop)
+	_ = mb.Push(1)
+	_ = mb.Push(2)
+	_ = mb.Push(3)
+	assert.Equal(t, 2, mb.Len())
+	assert.Equal(t, 3, dropped)
+
+	elem, _ = mb.Pop()
+	assert.Equal(t, 2, elem)
+	elem, _ = mb.Pop()
+	assert.Equal(t, 3, elem)
+	assert.Equal(t, 0, mb.Len())
+}
+
+func TestMailboxLockFreeBlock(t *testing.T) {
+	mb := New(LockFree(), Bounded(1, BLOCK_POLICY))
+
+	_ = mb.Push(1)
+
+	pushed := make(chan bool)
+
+	go func() {
+		_ = mb.Push(2)
+		pushed <- true
+	}()
+
+	select {
+	case <-pushed:
+		t.Fail()
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	elem, _ := mb.Pop()
+	assert.Equal(t, 1, elem)
+	<-pushed
+
+	elem, _ = mb.Pop()
+	assert.Equal(t, 2, elem)
+
+	mb.Close()
+	assert.PanicsWithValue(t, ErrClosed, func() {
+		_ = mb.Push(3)
+	})
+}
+
+func benchmarkMailboxChannel(b *testing.B, producers int) {
+	mb := New()
+	out := mb.Out()
+
+	b.ResetTimer()
+
+	for p := 0; p < producers; p++ {
+		go func() {
+			for i := 0; i < b.N/producers; i++ {
+				_ = mb.Push(i)
+			}
+		}()
+	}
+
+	for i := 0; i < b.N/producers*producers; i++ {
+		<-out
+	}
+}
+
+func benchmarkMailboxLockFree(b *testing.B, producers int) {
+	mb := New(LockFree())
+
+	b.ResetTimer()
+
+	for p := 0; p < producers; p++ {
+		go func() {
+			for i := 0; i < b.N/producers; i++ {
+				_ = mb.Push(i)
+			}
+		}()
+	}
+
+	for i := 0; i < b.N/producers*producers; {
+		if _, ok := mb.Pop(); ok {
+			i++
+			continue
+		}
+
+		<-mb.Wait()
+	}
+}
+
+func BenchmarkMailbox(b *testing.B) {
+	benchmarkMailboxChannel(b, 1)
+}
+
+func BenchmarkMailboxLockFree(b *testing.B) {
+	benchmarkMailboxLockFree(b, 1)
+}
+
+func BenchmarkMailboxProducers(b *testing.B) {
+	benchmarkMailboxChannel(b, 8)
+}
+
+func BenchmarkMailboxLockFreeProducers(b *testing.B) {
+	benchmarkMailboxLockFree(b, 8)
+}
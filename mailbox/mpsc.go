@@ -0,0 +1,60 @@
+package mailbox
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+//mpscQueue is an unbounded, lock-free multi-producer single-consumer
+//queue (after Dmitry Vyukov's intrusive MPSC node-based queue).
+//push can be called from any goroutine, pop and empty may only be
+//called by the consumer.
+type mpscQueue struct {
+	//head is where producers append (swapped atomically)
+	head unsafe.Pointer
+	//tail is the stub node before the next element (only touched by the consumer)
+	tail *mpscNode
+}
+
+type mpscNode struct {
+	next  unsafe.Pointer
+	value interface{}
+}
+
+func newMpscQueue() *mpscQueue {
+	stub := &mpscNode{}
+
+	return &mpscQueue{
+		head: unsafe.Pointer(stub),
+		tail: stub,
+	}
+}
+
+func (q *mpscQueue) push(value interface{}) {
+	n := &mpscNode{value: value}
+
+	prev := (*mpscNode)(atomic.SwapPointer(&q.head, unsafe.Pointer(n)))
+
+	//between the swap and this store, the consumer can't see the new node yet
+	atomic.StorePointer(&prev.next, unsafe.Pointer(n))
+}
+
+func (q *mpscQueue) pop() (interface{}, bool) {
+	next := (*mpscNode)(atomic.LoadPointer(&q.tail.next))
+
+	if next == nil {
+		return nil, false
+	}
+
+	//next becomes the new stub
+	q.tail = next
+
+	value := next.value
+	next.value = nil
+
+	return value, true
+}
+
+func (q *mpscQueue) empty() bool {
+	return atomic.LoadPointer(&q.tail.next) == nil
+}
@@ -0,0 +1,156 @@
+package mailbox
+
+//PushSystem pushes an element into the system lane of a
+//scheduled or lock-free Mailbox. Elements in the system lane
+//skip ahead of all other elements and are never dropped or
+//rejected. Pushing to a closed Mailbox panics.
+func (mb *Mailbox) PushSystem(elem interface{}) {
+	if mb.lockFree {
+		mb.pushSystemLockFree(elem)
+		return
+	}
+
+	mb.mu.Lock()
+
+	if mb.closed {
+		mb.mu.Unlock()
+		panic(ErrClosed)
+	}
+
+	mb.systemQueue.PushBack(elem)
+	mb.mu.Unlock()
+
+	mb.notify()
+}
+
+//Pop removes the next element from a scheduled or lock-free
+//Mailbox. Pop doesn't block and returns false if the Mailbox
+//is empty.
+func (mb *Mailbox) Pop() (interface{}, bool) {
+	if mb.lockFree {
+		return mb.popLockFree()
+	}
+
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	lane := mb.systemQueue
+
+	if lane.Len() == 0 && mb.priorityQueue != nil {
+		lane = mb.priorityQueue
+	}
+
+	if lane.Len() == 0 {
+		lane = mb.queue
+	}
+
+	if lane.Len() == 0 {
+		return nil, false
+	}
+
+	elem := lane.Remove(lane.Front())
+
+	if lane == mb.queue {
+		//a sender might be waiting for room (see BLOCK_POLICY)
+		mb.notFull.Signal()
+	}
+
+	return elem, true
+}
+
+//Pending returns true if there is at least one element
+//(including the system lane) in a scheduled or lock-free
+//Mailbox.
+func (mb *Mailbox) Pending() bool {
+	if mb.lockFree {
+		return mb.pendingLockFree()
+	}
+
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	return mb.systemQueue.Len() != 0 || mb.queue.Len() != 0 ||
+		(mb.priorityQueue != nil && mb.priorityQueue.Len() != 0)
+}
+
+//Wait returns a channel that the consumer of a scheduled or
+//lock-free Mailbox can wait on until there is an element to pop.
+//This way, the consumer can park (e.g. when it is looking for a
+//specific element) without having to poll. The channel might
+//fire spuriously, so Pop can still come up empty.
+func (mb *Mailbox) Wait() <-chan bool {
+	if mb.Pending() {
+		return readyNow
+	}
+
+	if mb.lockFree {
+		mb.parked.Store(true)
+
+		//something might have been pushed before we parked
+		if mb.Pending() {
+			mb.parked.Store(false)
+			return readyNow
+		}
+	}
+
+	return mb.readyChan
+}
+
+func (mb *Mailbox) notify() {
+	select {
+	case mb.readyChan <- true:
+	default:
+	}
+
+	mb.schedule()
+}
+
+func (mb *Mailbox) pushScheduled(elem interface{}) error {
+	mb.mu.Lock()
+
+	normal := mb.lane(elem) == mb.queue
+
+	for normal && mb.policy == BLOCK_POLICY && mb.full() && !mb.closed {
+		mb.notFull.Wait()
+	}
+
+	if mb.closed {
+		mb.mu.Unlock()
+		panic(ErrClosed)
+	}
+
+	if normal && mb.policy == FAIL_POLICY && mb.full() {
+		mb.mu.Unlock()
+		mb.drop(1)
+		return ErrFull
+	}
+
+	mb.push(elem)
+	mb.mu.Unlock()
+
+	mb.notify()
+
+	return nil
+}
+
+func (mb *Mailbox) pushFrontScheduled(elems []interface{}) {
+	mb.mu.Lock()
+
+	for i := len(elems) - 1; i >= 0; i-- {
+		mb.lane(elems[i]).PushFront(elems[i])
+	}
+
+	mb.mu.Unlock()
+
+	mb.notify()
+}
+
+func (mb *Mailbox) closeScheduled() {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	mb.closed = true
+
+	//senders that are blocked on a full mailbox panic
+	mb.notFull.Broadcast()
+}
@@ -0,0 +1,30 @@
+package quacktors
+
+//A Matcher decides whether a Message should be picked up
+//by Context.Receive. Messages that don't match stay in
+//the mailbox of the actor.
+type Matcher func(message Message) bool
+
+//MatchType returns a Matcher that matches every Message
+//with the same Message.Type as the provided Message.
+func MatchType(message Message) Matcher {
+	t := message.Type()
+
+	return func(m Message) bool {
+		return m.Type() == t
+	}
+}
+
+//MatchAny returns a Matcher that matches any of the
+//provided Matchers.
+func MatchAny(matchers ...Matcher) Matcher {
+	return func(m Message) bool {
+		for _, matcher := range matchers {
+			if matcher(m) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
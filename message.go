@@ -1,10 +1,25 @@
 package quacktors
 
-import "github.com/opentracing/opentracing-go"
+import (
+	"fmt"
+	"github.com/opentracing/opentracing-go"
+)
 
 type localMessage struct {
 	message     Message
 	spanContext opentracing.SpanContext
+	//sender is nil if the message wasn't sent by an actor
+	sender *Pid
+	//replyTo is set if the message was sent via Context.Ask
+	replyTo *Pid
+	//timer is set if the message was sent by a timer of an actor
+	timer *timerTag
+}
+
+//stale returns true if the message was sent by a timer that
+//was canceled or replaced in the meantime.
+func (m localMessage) stale() bool {
+	return m.timer != nil && !m.timer.claim()
 }
 
 //The Message interface defines all methods a struct has
@@ -16,11 +31,75 @@ type Message interface {
 	Type() string
 }
 
+//The PriorityMessage interface can optionally be implemented
+//by a Message. If an Actor was spawned with a priority mailbox
+//(see WithPriorityMailbox) and Priority returns true, the
+//Message skips ahead of all normal messages in the mailbox.
+type PriorityMessage interface {
+	Message
+
+	//Priority returns true if the Message should skip
+	//ahead of normal messages.
+	Priority() bool
+}
+
+//ExitKind describes how an Actor went down.
+type ExitKind string
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	//The NORMAL_EXIT indicates that an Actor quit by calling Context.Quit.
+	NORMAL_EXIT ExitKind = "normal"
+
+	//The POISON_PILL_EXIT indicates that an Actor was shut down by a PoisonPill.
+	POISON_PILL_EXIT ExitKind = "poison_pill"
+
+	//The KILLED_EXIT indicates that an Actor was killed (see Context.Kill).
+	KILLED_EXIT ExitKind = "killed"
+
+	//The PANIC_EXIT indicates that an Actor went down because of a panic.
+	PANIC_EXIT ExitKind = "panic"
+
+	//The NO_CONNECTION_EXIT indicates that the connection to the remote
+	//machine of an Actor went down (so we don't know if the Actor is still alive).
+	NO_CONNECTION_EXIT ExitKind = "noconnection"
+
+	//The NO_PROC_EXIT indicates that an Actor was already down (or never existed)
+	//when it was monitored.
+	NO_PROC_EXIT ExitKind = "noproc"
+)
+
+//The ExitReason struct describes why an Actor went down.
+type ExitReason struct {
+	//Kind is the way the Actor went down.
+	Kind ExitKind
+	//Value is the formatted panic value if Kind is PANIC_EXIT.
+	Value string
+	//Stack is the stack trace of the panic if Kind is PANIC_EXIT.
+	Stack string
+}
+
+//Abnormal returns true if the Actor didn't go down by
+//quitting or by receiving a PoisonPill.
+func (e ExitReason) Abnormal() bool {
+	return e.Kind != NORMAL_EXIT && e.Kind != POISON_PILL_EXIT
+}
+
+func (e ExitReason) String() string {
+	if e.Kind == PANIC_EXIT {
+		return fmt.Sprintf("%s: %s", e.Kind, e.Value)
+	}
+
+	return string(e.Kind)
+}
+
 //The DownMessage is sent to a monitoring Actor whenever
 //a monitored Actor goes down.
 type DownMessage struct {
 	//Who is the PID of the Actor that went down.
 	Who *Pid
+	//Reason is the reason why the Actor went down.
+	Reason ExitReason
 }
 
 //Type of DownMessage returns "DownMessage"
@@ -28,6 +107,49 @@ func (d DownMessage) Type() string {
 	return "quacktors/DownMessage"
 }
 
+//The ExitMessage is sent to an Actor that traps exits
+//(see Context.TrapExits) whenever a linked Actor goes down.
+type ExitMessage struct {
+	//Who is the PID of the linked Actor that went down.
+	Who *Pid
+	//Reason is the reason why the linked Actor went down.
+	Reason ExitReason
+}
+
+//Type of ExitMessage returns "ExitMessage"
+func (e ExitMessage) Type() string {
+	return "quacktors/ExitMessage"
+}
+
+//The FutureResultMessage is sent to an Actor when a Future
+//it was piped to (see Future.PipeTo) is resolved.
+type FutureResultMessage struct {
+	//Id is the ID of the Future (see Future.Id).
+	Id string
+	//Response is the reply of the asked Actor
+	//(nil if the Future failed).
+	Response Message
+	//Error is the error message if the Future failed
+	//(empty otherwise).
+	Error string
+}
+
+//Type of FutureResultMessage returns "FutureResultMessage"
+func (f FutureResultMessage) Type() string {
+	return "quacktors/FutureResultMessage"
+}
+
+//The ReceiveTimeoutMessage is sent to an Actor if it hasn't
+//received any Message within its receive timeout (see
+//Context.SetReceiveTimeout).
+type ReceiveTimeoutMessage struct {
+}
+
+//Type of ReceiveTimeoutMessage returns "ReceiveTimeoutMessage"
+func (r ReceiveTimeoutMessage) Type() string {
+	return "quacktors/ReceiveTimeoutMessage"
+}
+
 //A PoisonPill can be sent to an Actor to kill it without
 //aborting current (or already queued) messages. Instead,
 //it is enqueued into the actors mailbox and when the Actor
@@ -91,3 +213,18 @@ type DisconnectMessage struct {
 func (d DisconnectMessage) Type() string {
 	return "quacktors/DisconnectMessage"
 }
+
+//The MachineReconnectedMessage is sent to a monitoring Actor
+//whenever a monitored Machine connection went down and was
+//re-established (see config.SetReconnectPolicy).
+type MachineReconnectedMessage struct {
+	//MachineId is the ID of the Machine that reconnected.
+	MachineId string
+	//Address is the remote address of the Machine.
+	Address string
+}
+
+//Type of MachineReconnectedMessage returns "MachineReconnectedMessage"
+func (m MachineReconnectedMessage) Type() string {
+	return "quacktors/MachineReconnectedMessage"
+}
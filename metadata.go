@@ -15,6 +15,9 @@ var systemWg = &sync.WaitGroup{}
 var machines = map[string]*Machine{}
 var machinesMu = &sync.RWMutex{}
 
+var systems = make([]*System, 0)
+var systemsMu = &sync.Mutex{}
+
 func registerPid(pid *Pid) {
 	pidMapMu.Lock()
 	defer pidMapMu.Unlock()
@@ -59,6 +62,18 @@ func getMachine(machineId string) (*Machine, bool) {
 	return v, ok
 }
 
+func getMachines() []*Machine {
+	machinesMu.RLock()
+	defer machinesMu.RUnlock()
+
+	ms := make([]*Machine, 0, len(machines))
+	for _, m := range machines {
+		ms = append(ms, m)
+	}
+
+	return ms
+}
+
 func deleteMachine(machineId string) {
 	machinesMu.Lock()
 	defer machinesMu.Unlock()
@@ -66,6 +81,25 @@ func deleteMachine(machineId string) {
 	delete(machines, machineId)
 }
 
+func registerSystem(system *System) {
+	systemsMu.Lock()
+	defer systemsMu.Unlock()
+
+	systems = append(systems, system)
+}
+
+func getPids() []*Pid {
+	pidMapMu.RLock()
+	defer pidMapMu.RUnlock()
+
+	pids := make([]*Pid, 0, len(pidMap))
+	for _, pid := range pidMap {
+		pids = append(pids, pid)
+	}
+
+	return pids
+}
+
 //Run waits until all actors have quit.
 func Run() {
 	systemWg.Wait()
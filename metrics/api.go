@@ -1,10 +1,17 @@
 package metrics
 
-import "sync"
+import (
+	"go.uber.org/atomic"
+	"sync"
+)
 
 var recorders = make([]Recorder, 0)
 var recordersMu = &sync.RWMutex{}
 
+//registered is set as soon as the first recorder is registered so
+//that recording is free (no goroutine) as long as there are none
+var registered = atomic.NewBool(false)
+
 func RegisterRecorder(recorder Recorder) {
 	recordersMu.Lock()
 	defer recordersMu.Unlock()
@@ -12,9 +19,14 @@ func RegisterRecorder(recorder Recorder) {
 	recorder.Init()
 
 	recorders = append(recorders, recorder)
+	registered.Store(true)
 }
 
 func RecordSpawn(pid string) {
+	if !registered.Load() {
+		return
+	}
+
 	go func() {
 		recordersMu.RLock()
 		defer recordersMu.RUnlock()
@@ -26,6 +38,10 @@ func RecordSpawn(pid string) {
 }
 
 func RecordDie(pid string) {
+	if !registered.Load() {
+		return
+	}
+
 	go func() {
 		recordersMu.RLock()
 		defer recordersMu.RUnlock()
@@ -37,6 +53,10 @@ func RecordDie(pid string) {
 }
 
 func RecordDrop(pid string, amount int) {
+	if !registered.Load() {
+		return
+	}
+
 	go func() {
 		recordersMu.RLock()
 		defer recordersMu.RUnlock()
@@ -48,6 +68,10 @@ func RecordDrop(pid string, amount int) {
 }
 
 func RecordDropRemote(machine string, amount int) {
+	if !registered.Load() {
+		return
+	}
+
 	go func() {
 		recordersMu.RLock()
 		defer recordersMu.RUnlock()
@@ -59,6 +83,10 @@ func RecordDropRemote(machine string, amount int) {
 }
 
 func RecordUnhandled(target string) {
+	if !registered.Load() {
+		return
+	}
+
 	go func() {
 		recordersMu.RLock()
 		defer recordersMu.RUnlock()
@@ -70,6 +98,10 @@ func RecordUnhandled(target string) {
 }
 
 func RecordReceive(pid string) {
+	if !registered.Load() {
+		return
+	}
+
 	go func() {
 		recordersMu.RLock()
 		defer recordersMu.RUnlock()
@@ -81,6 +113,10 @@ func RecordReceive(pid string) {
 }
 
 func RecordReceiveRemote(pid string) {
+	if !registered.Load() {
+		return
+	}
+
 	go func() {
 		recordersMu.RLock()
 		defer recordersMu.RUnlock()
@@ -92,6 +128,10 @@ func RecordReceiveRemote(pid string) {
 }
 
 func RecordSendLocal(target string) {
+	if !registered.Load() {
+		return
+	}
+
 	go func() {
 		recordersMu.RLock()
 		defer recordersMu.RUnlock()
@@ -103,6 +143,10 @@ func RecordSendLocal(target string) {
 }
 
 func RecordSendRemote(target string) {
+	if !registered.Load() {
+		return
+	}
+
 	go func() {
 		recordersMu.RLock()
 		defer recordersMu.RUnlock()
@@ -0,0 +1,119 @@
+package quacktors
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+const nodeEnv = "QUACKTORS_TEST_NODE"
+const nodeReady = "quacktors test node ready"
+
+//TestNode isn't a test on its own, it runs the second node for the
+//tests that need two machines (see startNode)
+func TestNode(t *testing.T) {
+	name := os.Getenv(nodeEnv)
+	if name == "" {
+		return
+	}
+
+	//the node goes down with the test that started it
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, os.Stdin)
+		os.Exit(0)
+	}()
+
+	s, err := NewSystem(name)
+	if err != nil {
+		panic(err)
+	}
+
+	s.HandleRemote("echo", Spawn(func(ctx *Context, message Message) {
+		ctx.Reply(message)
+	}))
+
+	s.HandleRemote("quitter", Spawn(func(ctx *Context, message Message) {
+		ctx.Quit()
+	}))
+
+	fmt.Println(nodeReady)
+
+	Run()
+}
+
+//startNode starts a second quacktors node in a process of its own
+//and connects to it. The system of the node handles "echo" (replies
+//to every message with the message itself) and "quitter" (goes down
+//on the first message). The node is stopped once the test is done.
+func startNode(t *testing.T) *RemoteSystem {
+	name := "node" + uuidString()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestNode$", "-test.count=1")
+	cmd.Env = append(os.Environ(), nodeEnv+"="+name)
+
+	stdin, err := cmd.StdinPipe()
+	assert.NoError(t, err)
+
+	stdout, err := cmd.StdoutPipe()
+	assert.NoError(t, err)
+
+	assert.NoError(t, cmd.Start())
+
+	t.Cleanup(func() {
+		_ = stdin.Close()
+		_ = cmd.Wait()
+	})
+
+	ready := make(chan bool, 1)
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+
+		for scanner.Scan() {
+			if scanner.Text() == nodeReady {
+				ready <- true
+			}
+		}
+	}()
+
+	select {
+	case <-ready:
+	case <-time.After(10 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("test node didn't start")
+	}
+
+	r, err := Connect(name + "@localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	echo, err := r.Remote("echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the node connects back to us in the background (and drops its
+	//replies until it did), so wait until it can reach us
+	rootCtx := RootContext()
+	deadline := time.Now().Add(10 * time.Second)
+
+	for {
+		_, err := rootCtx.Ask(echo, GenericMessage{Value: "ping"}, 100*time.Millisecond).Await()
+		if err == nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("test node can't reach us")
+		}
+	}
+
+	return r
+}
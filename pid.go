@@ -2,6 +2,10 @@ package quacktors
 
 import (
 	"fmt"
+	"github.com/Azer0s/quacktors/mailbox"
+	"go.uber.org/atomic"
+	"sync"
+	"time"
 )
 
 //The Pid struct acts as a reference to an Actor.
@@ -13,25 +17,74 @@ type Pid struct {
 	MachineId     string
 	Id            string
 	quitChan      chan<- bool
-	messageChan   chan<- interface{}
+	mailbox       *mailbox.Mailbox //never changes after spawn
 	monitorChan   chan<- *Pid
 	demonitorChan chan<- *Pid
+	linkChan      chan<- *Pid
+	unlinkChan    chan<- *Pid
+	exitChan      chan<- ExitMessage
 	//Stores channels to scheduled tasks (monitors, SendAfter, monitors the actor itself launches but doesn't consume)
 	scheduled map[string]chan bool
 	//Stores channels to tell a monitor taks to quit (when a pid is demonitored)
 	monitorQuitChannels map[string]chan bool
+	//Is set right before the monitors are notified
+	exitReason ExitReason
+	//Stores the PIDs the actor is linked to (only ever touched by the actor itself)
+	links map[string]*Pid
+	//The PID of the actor that spawned this actor (nil if it was spawned globally)
+	parent *Pid
+	//Is closed as soon as the pid has been cleaned up
+	done chan bool
+	//Stores the running timers of the actor (SendInterval, StartTimer, etc.) by key
+	timers map[string]*actorTimer
+	//Stores the one-shot timers that fired at the actor itself until their message is received
+	firedTimers map[string]*actorTimer
+	timersMu    *sync.Mutex
+	//Stores the names the actor is registered under (guarded by namesMu)
+	names []string
+	//Stores the global names the actor is registered under (guarded by globalNamesMu)
+	globalNames []string
+	//Stores the process groups the actor is a member of (guarded by groupsMu)
+	groups []string
+	//Stores the actors that monitor the actor and the actors the actor monitors (see ProcessInfo)
+	monitors  map[string]*Pid
+	monitored map[string]*Pid
+	infoMu    *sync.Mutex
+	spawnedAt time.Time
+	received  *atomic.Uint64
+	sent      *atomic.Uint64
+	//The type of the message that is currently being processed
+	current *atomic.String
+	//Is set if the actor runs on a Dispatcher (system requests then go through the mailbox, never changes after spawn)
+	dispatched bool
 }
 
-func createPid(quitChan chan<- bool, messageChan chan<- interface{}, monitorChan chan<- *Pid, demonitorChan chan<- *Pid, scheduled map[string]chan bool, monitorQuitChannels map[string]chan bool) *Pid {
+func createPid(quitChan chan<- bool, mb *mailbox.Mailbox, monitorChan chan<- *Pid, demonitorChan chan<- *Pid, linkChan chan<- *Pid, unlinkChan chan<- *Pid, exitChan chan<- ExitMessage, scheduled map[string]chan bool, monitorQuitChannels map[string]chan bool, dispatched bool) *Pid {
 	pid := &Pid{
 		MachineId:           machineId,
 		Id:                  "",
 		quitChan:            quitChan,
-		messageChan:         messageChan,
+		mailbox:             mb,
 		monitorChan:         monitorChan,
 		demonitorChan:       demonitorChan,
+		linkChan:            linkChan,
+		unlinkChan:          unlinkChan,
+		exitChan:            exitChan,
 		scheduled:           scheduled,
 		monitorQuitChannels: monitorQuitChannels,
+		links:               make(map[string]*Pid),
+		done:                make(chan bool),
+		timers:              make(map[string]*actorTimer),
+		firedTimers:         make(map[string]*actorTimer),
+		timersMu:            &sync.Mutex{},
+		monitors:            make(map[string]*Pid),
+		monitored:           make(map[string]*Pid),
+		infoMu:              &sync.Mutex{},
+		spawnedAt:           time.Now(),
+		received:            atomic.NewUint64(0),
+		sent:                atomic.NewUint64(0),
+		current:             atomic.NewString(""),
+		dispatched:          dispatched,
 	}
 
 	registerPid(pid)
@@ -39,28 +92,58 @@ func createPid(quitChan chan<- bool, messageChan chan<- interface{}, monitorChan
 	return pid
 }
 
+//spawnedPid returns the instance of a local pid the actor was spawned
+//with (which holds its channels) or nil if the actor is already down.
+func spawnedPid(pid *Pid) *Pid {
+	//done is only set on the instance the actor was spawned with
+	if pid.done != nil {
+		return pid
+	}
+
+	p, ok := getByPidId(pid.Id)
+
+	if !ok {
+		return nil
+	}
+
+	return p
+}
+
 //Is compares two PIDs and returns true if their ID and MachineId are the same.
 func (pid *Pid) Is(other *Pid) bool {
 	return pid.Id == other.Id && pid.MachineId == other.MachineId
 }
 
-func (pid *Pid) cleanup() {
+func (pid *Pid) cleanup(reason ExitReason) {
 	logger.Debug("cleaning up pid",
 		"pid", pid.Id)
 
+	pid.exitReason = reason
+
 	deletePid(pid.Id)
+	unregisterNames(pid)
+	unregisterGlobalNames(pid)
+	leaveAllGroups(pid)
+
+	//the mailbox never changes after spawn, pushing to it fails once it's closed
+	pid.mailbox.Close()
 
-	close(pid.quitChan)
-	pid.quitChan = nil
+	if !pid.dispatched {
+		close(pid.quitChan)
+		pid.quitChan = nil
 
-	close(pid.messageChan)
-	pid.messageChan = nil
+		close(pid.monitorChan)
+		pid.monitorChan = nil
 
-	close(pid.monitorChan)
-	pid.monitorChan = nil
+		close(pid.demonitorChan)
+		pid.demonitorChan = nil
 
-	close(pid.demonitorChan)
-	pid.demonitorChan = nil
+		//the link, unlink and exit channels never change after spawn,
+		//senders give up on them as soon as done is closed (see spawnedPid)
+	}
+
+	//Timers don't outlive the actor
+	pid.stopTimers()
 
 	if len(pid.scheduled) != 0 {
 		//Terminate all scheduled events/send down message to monitor tasks
@@ -90,6 +173,19 @@ func (pid *Pid) cleanup() {
 	}
 
 	pid.monitorQuitChannels = nil
+
+	if len(pid.links) != 0 {
+		//Send out exit signals to all linked actors
+		logger.Debug("sending out exit signals to linked actors after pid cleanup",
+			"pid", pid.Id)
+
+		for _, link := range pid.links {
+			pid.removeLink(link)
+			sendExitSignal(pid, link, reason)
+		}
+	}
+
+	close(pid.done)
 }
 
 func (pid *Pid) setupMonitor(monitor *Pid) {
@@ -104,12 +200,14 @@ func (pid *Pid) setupMonitor(monitor *Pid) {
 	monitorQuitChannel := make(chan bool)
 	pid.monitorQuitChannels[name] = monitorQuitChannel
 
+	pid.addMonitor(monitor)
+
 	go func() {
 		select {
 		case <-monitorQuitChannel:
 			return
 		case <-monitorChannel:
-			doSend(monitor, DownMessage{Who: pid}, nil)
+			doSend(monitor, DownMessage{Who: pid, Reason: pid.exitReason}, nil)
 		}
 	}()
 }
@@ -125,11 +223,20 @@ func (pid *Pid) removeMonitor(monitor *Pid) {
 	delete(pid.monitorQuitChannels, name)
 	delete(pid.scheduled, name)
 
+	pid.removeMonitorInfo(monitor)
+
 	logger.Info("monitor removed successfully",
 		"monitored_pid", pid.Id,
 		"monitor_gpid", monitor.String())
 }
 
+//Parent returns the PID of the actor that spawned the actor
+//(see Context.Spawn). Parent returns nil if the actor was
+//spawned globally or if the PID belongs to a remote actor.
+func (pid *Pid) Parent() *Pid {
+	return pid.parent
+}
+
 func (pid *Pid) String() string {
 	return fmt.Sprintf("%s@%s", pid.Id, pid.MachineId)
 }
@@ -152,6 +259,11 @@ func (pid *Pid) die() {
 	logger.Debug("sending quit command to actor",
 		"pid", pid.Id)
 
+	if p := dispatchedPid(pid); p != nil {
+		p.pushSignal(quitSignal{})
+		return
+	}
+
 	if pid.quitChan == nil {
 		return
 	}
@@ -0,0 +1,205 @@
+package quacktors
+
+import (
+	"sort"
+	"sync"
+)
+
+//Process groups are replicated to every connected machine, just like
+//global names. Every machine only ever propagates the memberships of
+//its own actors (the remote machines remove the members of a machine
+//as soon as it disconnects).
+
+var groups = make(map[string]map[string]*Pid)
+var groupsMu = &sync.RWMutex{}
+
+func joinGroup(group string, pid *Pid) {
+	groupsMu.Lock()
+
+	if !addGroupMember(group, pid) {
+		groupsMu.Unlock()
+		return
+	}
+
+	pid.groups = append(pid.groups, group)
+
+	groupsMu.Unlock()
+
+	logger.Info("joined process group",
+		"pid", pid.Id,
+		"group", group)
+
+	broadcastGroupMembership(remoteGroupTuple{Group: group, Pid: pid})
+}
+
+func leaveGroup(group string, pid *Pid) {
+	groupsMu.Lock()
+
+	if !removeGroupMember(group, pid) {
+		groupsMu.Unlock()
+		return
+	}
+
+	for i, g := range pid.groups {
+		if g == group {
+			pid.groups = append(pid.groups[:i], pid.groups[i+1:]...)
+			break
+		}
+	}
+
+	groupsMu.Unlock()
+
+	logger.Info("left process group",
+		"pid", pid.Id,
+		"group", group)
+
+	broadcastGroupMembership(remoteGroupTuple{Group: group, Pid: pid, Leave: true})
+}
+
+func leaveAllGroups(pid *Pid) {
+	groupsMu.Lock()
+
+	left := pid.groups
+	for _, group := range left {
+		removeGroupMember(group, pid)
+	}
+
+	pid.groups = nil
+
+	groupsMu.Unlock()
+
+	for _, group := range left {
+		broadcastGroupMembership(remoteGroupTuple{Group: group, Pid: pid, Leave: true})
+	}
+}
+
+//addGroupMember has to be called with groupsMu locked
+func addGroupMember(group string, pid *Pid) bool {
+	members, ok := groups[group]
+
+	if !ok {
+		members = make(map[string]*Pid)
+		groups[group] = members
+	}
+
+	if _, ok := members[pid.String()]; ok {
+		return false
+	}
+
+	members[pid.String()] = pid
+
+	return true
+}
+
+//removeGroupMember has to be called with groupsMu locked
+func removeGroupMember(group string, pid *Pid) bool {
+	members, ok := groups[group]
+
+	if !ok {
+		return false
+	}
+
+	if _, ok := members[pid.String()]; !ok {
+		return false
+	}
+
+	delete(members, pid.String())
+
+	if len(members) == 0 {
+		delete(groups, group)
+	}
+
+	return true
+}
+
+//broadcastGroupMembership and syncGroups don't hold any lock while
+//sending (see broadcastGlobalName)
+func broadcastGroupMembership(t remoteGroupTuple) {
+	for _, m := range getMachines() {
+		if m.isConnected() {
+			m.groupChan <- t
+		}
+	}
+}
+
+//syncGroups sends the group memberships of all actors on the
+//local machine to a newly connected machine
+func syncGroups(m *Machine) {
+	groupsMu.RLock()
+
+	local := make([]remoteGroupTuple, 0)
+	for group, members := range groups {
+		for _, pid := range members {
+			if pid.MachineId == machineId {
+				local = append(local, remoteGroupTuple{Group: group, Pid: pid})
+			}
+		}
+	}
+
+	groupsMu.RUnlock()
+
+	for _, t := range local {
+		m.groupChan <- t
+	}
+}
+
+func handleRemoteGroupMembership(t remoteGroupTuple) {
+	groupsMu.Lock()
+	defer groupsMu.Unlock()
+
+	if t.Leave {
+		removeGroupMember(t.Group, t.Pid)
+		return
+	}
+
+	addGroupMember(t.Group, t.Pid)
+}
+
+//removeGroupMembersOfMachine removes all members of all groups
+//that are on a machine that disconnected
+func removeGroupMembersOfMachine(id string) {
+	groupsMu.Lock()
+	defer groupsMu.Unlock()
+
+	for group, members := range groups {
+		for _, pid := range members {
+			if pid.MachineId == id {
+				removeGroupMember(group, pid)
+			}
+		}
+	}
+}
+
+func getMembers(group string, localOnly bool) []*Pid {
+	groupsMu.RLock()
+	defer groupsMu.RUnlock()
+
+	members := make([]*Pid, 0, len(groups[group]))
+
+	for _, pid := range groups[group] {
+		if localOnly && pid.MachineId != machineId {
+			continue
+		}
+
+		members = append(members, pid)
+	}
+
+	//keep the order stable so picking from a group is predictable
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].String() < members[j].String()
+	})
+
+	return members
+}
+
+//Members returns the PIDs of all actors in a process group
+//(see Context.Join), no matter which machine they are on.
+func Members(group string) []*Pid {
+	return getMembers(group, false)
+}
+
+//LocalMembers returns the PIDs of all actors in a process
+//group that are on the local machine.
+func LocalMembers(group string) []*Pid {
+	return getMembers(group, true)
+}
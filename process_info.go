@@ -0,0 +1,207 @@
+package quacktors
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+//ErrProcessInfoTimeout is returned by ProcessInfo if the
+//remote machine of a PID didn't respond in time.
+var ErrProcessInfoTimeout = errors.New("process info request timed out")
+
+//ErrNoConnection is returned if the remote machine of a PID
+//is not connected.
+var ErrNoConnection = errors.New("remote machine is not connected")
+
+const processInfoTimeout = 5 * time.Second
+
+//The ActorInfo struct describes what an actor is doing at the
+//moment ProcessInfo was called. If the actor is down, only Pid
+//and Alive are set.
+type ActorInfo struct {
+	Pid   *Pid
+	Alive bool
+	//MailboxLen is the number of messages waiting in the mailbox
+	MailboxLen int
+	//Names are the local and global names the actor is registered under
+	Names       []string
+	GlobalNames []string
+	//Monitors are the actors that monitor the actor
+	Monitors []*Pid
+	//Monitored are the actors the actor monitors
+	Monitored []*Pid
+	//Timers are the keys of the running timers (see Context.StartTimer)
+	Timers    []string
+	SpawnedAt time.Time
+	//Received and Sent count the messages the actor has received and sent
+	Received uint64
+	Sent     uint64
+	//CurrentMessage is the type of the Message that is currently
+	//being processed (empty if the actor is idle)
+	CurrentMessage string
+}
+
+var processInfoRequests = make(map[string]chan ActorInfo)
+var processInfoRequestsMu = &sync.Mutex{}
+
+//ProcessInfo returns an ActorInfo that describes what the actor
+//behind a PID is doing. ProcessInfo works for local as well as
+//remote PIDs. If the remote machine of the PID is not connected,
+//ProcessInfo returns ErrNoConnection.
+func ProcessInfo(pid *Pid) (ActorInfo, error) {
+	callInitIfNotCalled()
+
+	if pid.MachineId == machineId {
+		return localProcessInfo(pid.Id), nil
+	}
+
+	m, ok := getMachine(pid.MachineId)
+
+	if !ok || !m.isConnected() {
+		return ActorInfo{}, ErrNoConnection
+	}
+
+	id := uuidString()
+	resultChan := make(chan ActorInfo, 1)
+
+	processInfoRequestsMu.Lock()
+	processInfoRequests[id] = resultChan
+	processInfoRequestsMu.Unlock()
+
+	defer func() {
+		processInfoRequestsMu.Lock()
+		delete(processInfoRequests, id)
+		processInfoRequestsMu.Unlock()
+	}()
+
+	m.processInfoChan <- remoteProcessInfoTuple{RequestId: id, Pid: pid}
+
+	select {
+	case info := <-resultChan:
+		return info, nil
+	case <-time.After(processInfoTimeout):
+		return ActorInfo{}, ErrProcessInfoTimeout
+	}
+}
+
+func localProcessInfo(pidId string) ActorInfo {
+	pid, ok := getByPidId(pidId)
+
+	if !ok {
+		return ActorInfo{
+			Pid:   &Pid{MachineId: machineId, Id: pidId},
+			Alive: false,
+		}
+	}
+
+	info := ActorInfo{
+		Pid:       pid,
+		Alive:     true,
+		SpawnedAt: pid.spawnedAt,
+		Received:  pid.received.Load(),
+		Sent:      pid.sent.Load(),
+		//the mailbox never changes after spawn (even if the actor goes down in the meantime)
+		MailboxLen: pid.mailbox.Len(),
+	}
+
+	info.CurrentMessage = pid.current.Load()
+
+	namesMu.RLock()
+	info.Names = append(make([]string, 0), pid.names...)
+	namesMu.RUnlock()
+
+	globalNamesMu.RLock()
+	info.GlobalNames = append(make([]string, 0), pid.globalNames...)
+	globalNamesMu.RUnlock()
+
+	pid.infoMu.Lock()
+	info.Monitors = sortedPids(pid.monitors)
+	info.Monitored = sortedPids(pid.monitored)
+	pid.infoMu.Unlock()
+
+	pid.timersMu.Lock()
+	info.Timers = make([]string, 0, len(pid.timers))
+	for key := range pid.timers {
+		info.Timers = append(info.Timers, key)
+	}
+	pid.timersMu.Unlock()
+
+	sort.Strings(info.Timers)
+
+	return info
+}
+
+func sortedPids(pids map[string]*Pid) []*Pid {
+	ret := make([]*Pid, 0, len(pids))
+
+	for _, pid := range pids {
+		ret = append(ret, pid)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].String() < ret[j].String()
+	})
+
+	return ret
+}
+
+//handleRemoteProcessInfo resolves a pending ProcessInfo call
+//with the ActorInfo a remote machine sent back
+func handleRemoteProcessInfo(requestId string, rawInfo map[string]interface{}) error {
+	info, err := decodeValueByInterface(ActorInfo{}, rawInfo)
+
+	if err != nil {
+		return err
+	}
+
+	processInfoRequestsMu.Lock()
+	resultChan, ok := processInfoRequests[requestId]
+	processInfoRequestsMu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	//the request is sent again after a reconnect, so the result might arrive twice
+	select {
+	case resultChan <- info.(ActorInfo):
+	default:
+	}
+
+	return nil
+}
+
+func (pid *Pid) addMonitor(monitor *Pid) {
+	pid.infoMu.Lock()
+	defer pid.infoMu.Unlock()
+
+	pid.monitors[monitor.String()] = monitor
+}
+
+func (pid *Pid) removeMonitorInfo(monitor *Pid) {
+	pid.infoMu.Lock()
+	defer pid.infoMu.Unlock()
+
+	delete(pid.monitors, monitor.String())
+}
+
+func (pid *Pid) addMonitored(monitored *Pid) {
+	pid.infoMu.Lock()
+	defer pid.infoMu.Unlock()
+
+	pid.monitored[monitored.String()] = monitored
+}
+
+func (pid *Pid) removeMonitored(monitored *Pid) {
+	if pid.infoMu == nil {
+		//the PID was decoded from a remote machine
+		return
+	}
+
+	pid.infoMu.Lock()
+	defer pid.infoMu.Unlock()
+
+	delete(pid.monitored, monitored.String())
+}
@@ -17,7 +17,7 @@ func qpmdRegister(system *System, systemPort uint16) (net.Conn, error) {
 		return nil, err
 	}
 
-	err = sendRequest(conn, qpmd.Request{
+	err = sendQpmdRequest(conn, qpmd.Request{
 		RequestType: qpmd.REQUEST_REGISTER,
 		Data: map[string]interface{}{
 			qpmd.SYSTEM_NAME: system.name,
@@ -30,7 +30,7 @@ func qpmdRegister(system *System, systemPort uint16) (net.Conn, error) {
 		return nil, err
 	}
 
-	res, err := readResponse(conn)
+	res, err := readQpmdResponse(conn)
 	if err != nil {
 		return nil, err
 	}
@@ -45,17 +45,19 @@ func qpmdRegister(system *System, systemPort uint16) (net.Conn, error) {
 func qpmdHeartbeat(conn net.Conn, system *System) {
 	quit := func() {
 		logger.Error("qpmd heartbeat was quit unexpectedly serverside, is qpmd still running?")
-		system.quitChan <- true
-		system.closed = true
+		system.closed.Store(true)
+		system.closeServer()
 	}
 
 	go func() {
 		for {
 			select {
 			case <-system.heartbeatQuitChan:
+				//closing the connection deregisters the system from qpmd
+				_ = conn.Close()
 				return
 			case <-time.After(25 * time.Second):
-				err := sendRequest(conn, qpmd.Request{
+				err := sendQpmdRequest(conn, qpmd.Request{
 					RequestType: qpmd.HEARTBEAT,
 					Data:        make(map[string]interface{}),
 				})
@@ -64,7 +66,7 @@ func qpmdHeartbeat(conn net.Conn, system *System) {
 					return
 				}
 
-				res, err := readResponse(conn)
+				res, err := readQpmdResponse(conn)
 
 				if err != nil || res.ResponseType != qpmd.RESPONSE_OK {
 					quit()
@@ -85,7 +87,7 @@ func qpmdLookup(system, remoteAddress string) (*RemoteSystem, error) {
 		return &RemoteSystem{}, err
 	}
 
-	err = sendRequest(conn, qpmd.Request{
+	err = sendQpmdRequest(conn, qpmd.Request{
 		RequestType: qpmd.REQUEST_LOOKUP,
 		Data: map[string]interface{}{
 			"system": system,
@@ -95,7 +97,7 @@ func qpmdLookup(system, remoteAddress string) (*RemoteSystem, error) {
 		return &RemoteSystem{}, err
 	}
 
-	res, err := readResponse(conn)
+	res, err := readQpmdResponse(conn)
 	if err != nil {
 		return &RemoteSystem{}, err
 	}
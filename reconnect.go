@@ -0,0 +1,113 @@
+package quacktors
+
+import (
+	"github.com/Azer0s/quacktors/config"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const defaultInitialBackoff = 100 * time.Millisecond
+
+//reconnectPolicy is nil if machines shouldn't reconnect (guarded by reconnectPolicyMu)
+var reconnectPolicy *config.ReconnectPolicy
+var reconnectPolicyMu = &sync.RWMutex{}
+
+func setReconnectPolicy(policy *config.ReconnectPolicy) {
+	reconnectPolicyMu.Lock()
+	defer reconnectPolicyMu.Unlock()
+
+	reconnectPolicy = policy
+}
+
+func getReconnectPolicy() *config.ReconnectPolicy {
+	reconnectPolicyMu.RLock()
+	defer reconnectPolicyMu.RUnlock()
+
+	return reconnectPolicy
+}
+
+//reconnectDelay returns how long to wait before the nth (starting at 0) attempt
+func reconnectDelay(policy *config.ReconnectPolicy, attempt int) time.Duration {
+	delay := policy.InitialBackoff
+
+	if delay <= 0 {
+		delay = defaultInitialBackoff
+	}
+
+	for i := 0; i < attempt; i++ {
+		if policy.MaxBackoff > 0 && delay >= policy.MaxBackoff {
+			break
+		}
+
+		delay *= 2
+	}
+
+	if policy.MaxBackoff > 0 && delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+
+	jitter := policy.Jitter
+
+	if jitter <= 0 {
+		return delay
+	}
+
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	//the delay ends up somewhere between (1 - jitter) * delay and delay
+	return delay - time.Duration(rand.Float64()*jitter*float64(delay))
+}
+
+//reconnect restarts the connections to a machine whose connection dropped.
+//In the meantime, the machine stays registered, messages are queued in its
+//mailbox and monitors stay in place. The machine is only disconnected for good
+//(i.e. monitors get a DisconnectMessage) if all attempts failed.
+func (m *Machine) reconnect(policy *config.ReconnectPolicy) {
+	logger.Warn("lost connection to remote machine, reconnecting",
+		"machine_id", m.MachineId)
+
+	//the connections might still be open if only one of them dropped
+	m.gatewayQuitChan <- true
+	m.gpQuitChan <- true
+
+	for attempt := 0; policy.MaxAttempts == 0 || attempt < policy.MaxAttempts; attempt++ {
+		<-time.After(reconnectDelay(policy, attempt))
+
+		if shuttingDown.Load() {
+			break
+		}
+
+		logger.Info("attempting to reconnect to remote machine",
+			"machine_id", m.MachineId,
+			"attempt", attempt+1)
+
+		if m.start() != nil {
+			continue
+		}
+
+		m.reconnecting.Store(false)
+
+		logger.Info("successfully reconnected to remote machine",
+			"machine_id", m.MachineId)
+
+		m.monitorsMu.Lock()
+		for _, monitor := range m.monitors {
+			doSend(monitor, MachineReconnectedMessage{MachineId: m.MachineId, Address: m.Address}, nil)
+		}
+		m.monitorsMu.Unlock()
+
+		//the remote machine might have forgotten about our global names and process groups
+		syncGlobalNames(m)
+		syncGroups(m)
+
+		return
+	}
+
+	logger.Warn("couldn't reconnect to remote machine, giving up",
+		"machine_id", m.MachineId)
+
+	m.disconnect()
+}
@@ -0,0 +1,396 @@
+package quacktors
+
+import (
+	"fmt"
+	"github.com/Azer0s/qpmd"
+	"github.com/Azer0s/quacktors/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	policy := &config.ReconnectPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	}
+
+	assert.Equal(t, 10*time.Millisecond, reconnectDelay(policy, 0))
+	assert.Equal(t, 20*time.Millisecond, reconnectDelay(policy, 1))
+	assert.Equal(t, 40*time.Millisecond, reconnectDelay(policy, 2))
+	assert.Equal(t, 50*time.Millisecond, reconnectDelay(policy, 3))
+	assert.Equal(t, 50*time.Millisecond, reconnectDelay(policy, 100))
+
+	policy.Jitter = 0.5
+
+	for i := 0; i < 100; i++ {
+		d := reconnectDelay(policy, 1)
+		assert.True(t, d >= 10*time.Millisecond && d <= 20*time.Millisecond)
+	}
+
+	assert.Equal(t, defaultInitialBackoff, reconnectDelay(&config.ReconnectPolicy{}, 0))
+}
+
+//flakyMachine is a fake remote machine that can drop its connections
+type flakyMachine struct {
+	messageListener net.Listener
+	gpListener      net.Listener
+	down            *atomic.Bool
+	connsMu         *sync.Mutex
+	conns           []net.Conn
+	requests        chan qpmd.Request
+}
+
+func newFlakyMachine(t *testing.T) *flakyMachine {
+	//the listeners use TLS if it's enabled (just like a real machine would)
+	messageListener, err := listen()
+	assert.NoError(t, err)
+
+	gpListener, err := listen()
+	assert.NoError(t, err)
+
+	f := &flakyMachine{
+		messageListener: messageListener,
+		gpListener:      gpListener,
+		down:            atomic.NewBool(false),
+		connsMu:         &sync.Mutex{},
+		requests:        make(chan qpmd.Request, 100),
+	}
+
+	//messages are handled by our own gateway code (so they end up at our local actors)
+	go f.accept(messageListener, handleMessageClient)
+
+	go f.accept(gpListener, func(conn net.Conn) {
+		_, err := serverHandshake(conn)
+		if err != nil {
+			return
+		}
+
+		for {
+			req, err := readRequest(conn)
+			if err != nil {
+				return
+			}
+
+			f.requests <- req
+		}
+	})
+
+	return f
+}
+
+func (f *flakyMachine) accept(listener net.Listener, handle func(conn net.Conn)) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		if f.down.Load() {
+			_ = conn.Close()
+			continue
+		}
+
+		f.connsMu.Lock()
+		f.conns = append(f.conns, conn)
+		f.connsMu.Unlock()
+
+		go handle(conn)
+	}
+}
+
+func (f *flakyMachine) drop() {
+	f.down.Store(true)
+
+	f.connsMu.Lock()
+	defer f.connsMu.Unlock()
+
+	for _, conn := range f.conns {
+		_ = conn.Close()
+	}
+
+	f.conns = nil
+}
+
+func (f *flakyMachine) machine() *Machine {
+	return &Machine{
+		MachineId:          "flaky",
+		Address:            "127.0.0.1",
+		MessageGatewayPort: uint16(f.messageListener.Addr().(*net.TCPAddr).Port),
+		GeneralPurposePort: uint16(f.gpListener.Addr().(*net.TCPAddr).Port),
+	}
+}
+
+//connectBack connects the fake machine to our general purpose gateway (just like a real machine would)
+func (f *flakyMachine) connectBack(t *testing.T, m *Machine) net.Conn {
+	return f.connectBackTo(t, m, gpGatewayPort)
+}
+
+//connectBackTo connects the fake machine to a general purpose gateway on the given port
+func (f *flakyMachine) connectBackTo(t *testing.T, m *Machine, port uint16) net.Conn {
+	conn, err := dial("127.0.0.1", port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = clientHandshake(conn, map[string]interface{}{
+		qpmd.MACHINE_ID:           m.MachineId,
+		qpmd.MESSAGE_GATEWAY_PORT: m.MessageGatewayPort,
+		qpmd.GP_GATEWAY_PORT:      m.GeneralPurposePort,
+	})
+	assert.NoError(t, err)
+
+	f.connsMu.Lock()
+	f.conns = append(f.conns, conn)
+	f.connsMu.Unlock()
+
+	return conn
+}
+
+func (f *flakyMachine) close() {
+	_ = f.messageListener.Close()
+	_ = f.gpListener.Close()
+	f.drop()
+}
+
+func (f *flakyMachine) awaitRequest(t *testing.T, requestType qpmd.RequestType) qpmd.Request {
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case req := <-f.requests:
+			if req.RequestType == requestType {
+				return req
+			}
+		case <-timeout:
+			t.Fatalf("didn't receive %s request", requestType)
+			return qpmd.Request{}
+		}
+	}
+}
+
+func TestMachineReconnect(t *testing.T) {
+	rootCtx := RootContext()
+
+	old := getReconnectPolicy()
+	setReconnectPolicy(&config.ReconnectPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		MaxAttempts:    100,
+		Jitter:         0.5,
+	})
+	defer func() {
+		setReconnectPolicy(old)
+	}()
+
+	f := newFlakyMachine(t)
+	defer f.close()
+
+	m := f.machine()
+	assert.NoError(t, m.connect())
+	registerMachine(m)
+
+	f.connectBack(t, m)
+
+	events := make(chan Message, 10)
+
+	machineMonitor := SpawnWithInit(func(ctx *Context) {
+		ctx.MonitorMachine(m)
+	}, func(ctx *Context, message Message) {
+		events <- message
+	})
+
+	remote := &Pid{MachineId: "flaky", Id: "remote"}
+
+	monitor := SpawnWithInit(func(ctx *Context) {
+		ctx.Monitor(remote)
+	}, func(ctx *Context, message Message) {
+		events <- message
+	})
+
+	f.awaitRequest(t, monitorMessageType)
+
+	received := make(chan int, 100)
+
+	receiver := Spawn(func(ctx *Context, message Message) {
+		received <- int(message.(GenericMessage).Value.(int64))
+	})
+
+	//messages to the "remote" receiver go through the fake machine and come back to us
+	remoteReceiver := &Pid{MachineId: "flaky", Id: receiver.Id}
+
+	rootCtx.Send(remoteReceiver, GenericMessage{Value: int64(0)})
+	assert.Equal(t, 0, <-received)
+
+	f.drop()
+
+	assert.Eventually(t, func() bool {
+		return m.reconnecting.Load()
+	}, 5*time.Second, 5*time.Millisecond)
+
+	//during the outage, the messages are queued
+	for i := 1; i <= 10; i++ {
+		rootCtx.Send(remoteReceiver, GenericMessage{Value: int64(i)})
+	}
+
+	<-time.After(100 * time.Millisecond)
+	assert.Len(t, received, 0)
+	assert.True(t, m.isConnected())
+
+	f.down.Store(false)
+
+	select {
+	case e := <-events:
+		assert.Equal(t, MachineReconnectedMessage{MachineId: "flaky", Address: "127.0.0.1"}, e)
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't receive MachineReconnectedMessage")
+	}
+
+	//the monitor was re-established
+	req := f.awaitRequest(t, monitorMessageType)
+	assert.Equal(t, "remote", req.Data[toVal].(map[string]interface{})["Id"])
+
+	for i := 1; i <= 10; i++ {
+		select {
+		case v := <-received:
+			assert.Equal(t, i, v)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("didn't receive queued message %d", i)
+		}
+	}
+
+	//nothing else happened (i.e. no DownMessage and no DisconnectMessage)
+	assert.Len(t, events, 0)
+
+	rootCtx.Kill(machineMonitor)
+	rootCtx.Kill(monitor)
+	rootCtx.Kill(receiver)
+
+	//the actors are cleaned up before the machine disconnects (and sends them DownMessages)
+	<-machineMonitor.done
+	<-monitor.done
+
+	setReconnectPolicy(nil)
+	m.disconnect()
+
+	Run()
+}
+
+func TestMachineReconnectGiveUp(t *testing.T) {
+	RootContext()
+
+	old := getReconnectPolicy()
+	setReconnectPolicy(&config.ReconnectPolicy{
+		InitialBackoff: 5 * time.Millisecond,
+		MaxAttempts:    3,
+	})
+	defer func() {
+		setReconnectPolicy(old)
+	}()
+
+	f := newFlakyMachine(t)
+	defer f.close()
+
+	m := f.machine()
+	assert.NoError(t, m.connect())
+	registerMachine(m)
+
+	f.connectBack(t, m)
+
+	events := make(chan Message, 10)
+
+	SpawnWithInit(func(ctx *Context) {
+		ctx.MonitorMachine(m)
+	}, func(ctx *Context, message Message) {
+		events <- message
+		ctx.Quit()
+	})
+
+	f.drop()
+
+	select {
+	case e := <-events:
+		assert.Equal(t, DisconnectMessage{MachineId: "flaky", Address: "127.0.0.1"}, e)
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't receive DisconnectMessage")
+	}
+
+	_, ok := getMachine("flaky")
+	assert.False(t, ok)
+
+	Run()
+}
+
+func TestMachineReconnectReplay(t *testing.T) {
+	rootCtx := RootContext()
+
+	old := getReconnectPolicy()
+	setReconnectPolicy(&config.ReconnectPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	})
+	defer func() {
+		setReconnectPolicy(old)
+	}()
+
+	f := newFlakyMachine(t)
+	defer f.close()
+
+	m := f.machine()
+	assert.NoError(t, m.connect())
+	registerMachine(m)
+
+	remote := &Pid{MachineId: "flaky", Id: "remote"}
+
+	linker := SpawnWithInit(func(ctx *Context) {
+		ctx.TrapExits(true)
+		ctx.Link(remote)
+	}, func(ctx *Context, message Message) {})
+
+	f.awaitRequest(t, linkMessageType)
+
+	//without a connection back to us, we only notice that the
+	//connection dropped once a request can't be sent
+	f.drop()
+
+	failed := ""
+
+	for i := 0; i < 100 && failed == ""; i++ {
+		value := fmt.Sprintf("exit %d", i)
+
+		m.exitChan <- remoteExitTuple{From: linker, To: remote, Reason: ExitReason{Kind: KILLED_EXIT, Value: value}}
+
+		<-time.After(20 * time.Millisecond)
+
+		if m.reconnecting.Load() {
+			failed = value
+		}
+	}
+
+	assert.NotEqual(t, "", failed, "the connection drop wasn't noticed")
+
+	f.down.Store(false)
+
+	//the link was re-established...
+	req := f.awaitRequest(t, linkMessageType)
+	assert.Equal(t, linker.Id, req.Data[fromVal].(map[string]interface{})["Id"])
+	assert.Equal(t, "remote", req.Data[toVal].(map[string]interface{})["Id"])
+
+	//...and the request that couldn't be sent wasn't lost
+	for req.Data[reasonVal] == nil || req.Data[reasonVal].(map[string]interface{})["Value"] != failed {
+		req = f.awaitRequest(t, exitMessageType)
+	}
+
+	rootCtx.Kill(linker)
+
+	//the actor is cleaned up before the machine disconnects (and sends it an exit signal)
+	<-linker.done
+
+	setReconnectPolicy(nil)
+	m.disconnect()
+
+	Run()
+}
@@ -0,0 +1,80 @@
+package quacktors
+
+import (
+	"errors"
+	"sync"
+)
+
+//ErrNameTaken is returned by Context.Register if another
+//actor is already registered under the same name.
+var ErrNameTaken = errors.New("name is already registered")
+
+//ErrNameNotFound is returned if there is no actor registered
+//under a name (see Context.SendNamed and Context.MonitorNamed).
+var ErrNameNotFound = errors.New("name is not registered")
+
+var names = make(map[string]*Pid)
+var namesMu = &sync.RWMutex{}
+
+func registerName(name string, pid *Pid) error {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+
+	if _, ok := names[name]; ok {
+		return ErrNameTaken
+	}
+
+	names[name] = pid
+	pid.names = append(pid.names, name)
+
+	logger.Info("registered name",
+		"pid", pid.Id,
+		"name", name)
+
+	return nil
+}
+
+func unregisterName(name string, pid *Pid) {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+
+	if p, ok := names[name]; !ok || !p.Is(pid) {
+		return
+	}
+
+	delete(names, name)
+
+	for i, n := range pid.names {
+		if n == name {
+			pid.names = append(pid.names[:i], pid.names[i+1:]...)
+			break
+		}
+	}
+
+	logger.Info("unregistered name",
+		"pid", pid.Id,
+		"name", name)
+}
+
+func unregisterNames(pid *Pid) {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+
+	for _, name := range pid.names {
+		delete(names, name)
+	}
+
+	pid.names = nil
+}
+
+//Whereis returns the PID of the actor that is registered
+//under a name (see Context.Register) and true, or nil and
+//false if there is no such actor.
+func Whereis(name string) (*Pid, bool) {
+	namesMu.RLock()
+	defer namesMu.RUnlock()
+
+	pid, ok := names[name]
+
+	return pid, ok
+}
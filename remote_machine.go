@@ -3,12 +3,12 @@ package quacktors
 import (
 	"bytes"
 	"errors"
-	"fmt"
 	"github.com/Azer0s/qpmd"
 	"github.com/Azer0s/quacktors/mailbox"
 	"github.com/Azer0s/quacktors/metrics"
 	"github.com/opentracing/opentracing-go"
 	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/atomic"
 	"net"
 	"sync"
 )
@@ -17,6 +17,16 @@ const quitMessageType = "quit"
 const monitorMessageType = "monitor"
 const demonitorMessageType = "demonitor"
 const newConnectionMessageType = "new_connection"
+const linkMessageType = "link"
+const unlinkMessageType = "unlink"
+const exitMessageType = "exit"
+const globalRegisterMessageType = "global_register"
+const globalUnregisterMessageType = "global_unregister"
+const groupJoinMessageType = "group_join"
+const groupLeaveMessageType = "group_leave"
+const processInfoMessageType = "process_info"
+const processInfoResultMessageType = "process_info_result"
+const authMessageType = "auth"
 
 const fromVal = "from"
 const toVal = "to"
@@ -24,12 +34,23 @@ const typeVal = "type"
 const spanCtx = "span_ctx"
 
 const messageVal = "message"
+const reasonVal = "reason"
+const replyToVal = "reply_to"
+const senderVal = "sender"
+const nameVal = "name"
+const groupVal = "group"
+const requestIdVal = "request_id"
+const infoVal = "info"
+const challengeVal = "challenge"
+const digestVal = "digest"
 
 const machineVal = "machine"
 
 //Machine is the struct representation of a remote machine.
 type Machine struct {
-	connected          bool
+	//connected, unsent, unsentRequest and unsentMu are zero values because
+	//machines are created all over the place (they are only usable after connect)
+	connected          atomic.Bool
 	MachineId          string
 	Address            string
 	MessageGatewayPort uint16
@@ -40,22 +61,99 @@ type Machine struct {
 	messageChan        chan<- interface{}
 	monitorChan        chan<- remoteMonitorTuple
 	demonitorChan      chan<- remoteMonitorTuple
+	linkChan           chan<- remoteLinkTuple
+	unlinkChan         chan<- remoteLinkTuple
+	exitChan           chan<- remoteExitTuple
+	globalNameChan     chan<- remoteGlobalNameTuple
+	groupChan          chan<- remoteGroupTuple
+	processInfoChan    chan<- remoteProcessInfoTuple
 	newConnectionChan  chan<- *Machine
-	//Stores channels to scheduled monitors
+	//Stores channels to scheduled monitors (and links)
 	scheduled map[string]chan bool
 	//Stores channels to tell a monitor task to quit (when a pid is demonitored)
 	monitorQuitChannels map[string]chan bool
 	monitorsMu          *sync.Mutex
+	//Stores the actors that monitor the connection (see Context.MonitorMachine)
+	monitors map[string]*Pid
+	//Stores the monitors of local actors on remote actors so they can be re-established after a reconnect
+	remoteMonitors map[string]remoteMonitorTuple
+	//Stores the links of local actors to remote actors so they can be re-established after a reconnect
+	remoteLinks map[string]remoteLinkTuple
+	//start (re)starts the connections to the remote machine (see connect)
+	start        func() error
+	reconnecting *atomic.Bool
+	//Is set if a message couldn't be sent because the connection dropped (it's sent first after a reconnect)
+	unsent *remoteMessageTuple
+	//Is set if a request couldn't be sent because the connection dropped (it's sent first after a reconnect)
+	unsentRequest *qpmd.Request
+	unsentMu      sync.Mutex
+}
+
+func (m *Machine) isConnected() bool {
+	return m.connected.Load()
+}
+
+func (m *Machine) setUnsent(message *remoteMessageTuple) {
+	m.unsentMu.Lock()
+	defer m.unsentMu.Unlock()
+
+	m.unsent = message
+}
+
+func (m *Machine) takeUnsent() *remoteMessageTuple {
+	m.unsentMu.Lock()
+	defer m.unsentMu.Unlock()
+
+	message := m.unsent
+	m.unsent = nil
+
+	return message
+}
+
+func (m *Machine) setUnsentRequest(req *qpmd.Request) {
+	m.unsentMu.Lock()
+	defer m.unsentMu.Unlock()
+
+	m.unsentRequest = req
+}
+
+func (m *Machine) takeUnsentRequest() *qpmd.Request {
+	m.unsentMu.Lock()
+	defer m.unsentMu.Unlock()
+
+	req := m.unsentRequest
+	m.unsentRequest = nil
+
+	return req
 }
 
 func (m *Machine) stop() {
+	if policy := getReconnectPolicy(); policy != nil && !shuttingDown.Load() {
+		if m.reconnecting.CAS(false, true) {
+			go m.reconnect(policy)
+		}
+
+		return
+	}
+
+	//connection failures while we're reconnecting are expected
+	if m.reconnecting.Load() {
+		return
+	}
+
+	m.disconnect()
+}
+
+func (m *Machine) disconnect() {
 	go func() {
-		m.connected = false
+		m.connected.Store(false)
 
 		logger.Info("stopping connections to remote machine",
 			"machine_id", m.MachineId)
 
 		deleteMachine(m.MachineId)
+		removeGlobalNamesOfMachine(m.MachineId)
+		removeGroupMembersOfMachine(m.MachineId)
 
 		m.gatewayQuitChan <- true
 		m.gpQuitChan <- true
@@ -101,6 +199,8 @@ func (m *Machine) setupMonitor(monitor *Pid) {
 	monitorQuitChannel := make(chan bool)
 	m.monitorQuitChannels[name] = monitorQuitChannel
 
+	m.monitors[name] = monitor
+
 	go func() {
 		select {
 		case <-monitorQuitChannel:
@@ -120,6 +220,8 @@ func (m *Machine) setupRemoteMonitor(r remoteMonitorTuple) {
 	monitorChannel := make(chan bool)
 	m.scheduled[name] = monitorChannel
 
+	m.remoteMonitors[name] = r
+
 	monitorQuitChannel := make(chan bool)
 	m.monitorQuitChannels[name] = monitorQuitChannel
 
@@ -128,7 +230,7 @@ func (m *Machine) setupRemoteMonitor(r remoteMonitorTuple) {
 		case <-monitorQuitChannel:
 			return
 		case <-monitorChannel:
-			doSend(r.From, DownMessage{Who: r.To}, nil)
+			doSend(r.From, DownMessage{Who: r.To, Reason: ExitReason{Kind: NO_CONNECTION_EXIT}}, nil)
 		}
 	}()
 }
@@ -139,23 +241,156 @@ func (m *Machine) removeRemoteMonitor(r remoteMonitorTuple) {
 
 	name := r.From.String() + "_" + r.To.String()
 
-	m.monitorQuitChannels[name] <- true
+	//the machine might have disconnected in the meantime
+	monitorQuitChannel, ok := m.monitorQuitChannels[name]
+	if !ok {
+		return
+	}
+
+	monitorQuitChannel <- true
 
 	delete(m.scheduled, name)
 	delete(m.monitorQuitChannels, name)
+	delete(m.remoteMonitors, name)
+}
+
+func (m *Machine) setupRemoteLink(local *Pid, remote *Pid) {
+	m.monitorsMu.Lock()
+	defer m.monitorsMu.Unlock()
+
+	name := "link_" + local.String() + "_" + remote.String()
+
+	linkChannel := make(chan bool)
+	m.scheduled[name] = linkChannel
+
+	m.remoteLinks[name] = remoteLinkTuple{From: local, To: remote}
+
+	linkQuitChannel := make(chan bool)
+	m.monitorQuitChannels[name] = linkQuitChannel
+
+	go func() {
+		select {
+		case <-linkQuitChannel:
+			return
+		case <-linkChannel:
+			sendExitSignal(remote, local, ExitReason{Kind: NO_CONNECTION_EXIT})
+		}
+	}()
+}
+
+func (m *Machine) removeRemoteLink(local *Pid, remote *Pid) {
+	m.monitorsMu.Lock()
+	defer m.monitorsMu.Unlock()
+
+	name := "link_" + local.String() + "_" + remote.String()
+
+	//the machine might have disconnected in the meantime
+	linkQuitChannel, ok := m.monitorQuitChannels[name]
+	if !ok {
+		return
+	}
+
+	linkQuitChannel <- true
+
+	delete(m.scheduled, name)
+	delete(m.monitorQuitChannels, name)
+	delete(m.remoteLinks, name)
+}
+
+func (m *Machine) getRemoteMonitors() []remoteMonitorTuple {
+	m.monitorsMu.Lock()
+	defer m.monitorsMu.Unlock()
+
+	monitors := make([]remoteMonitorTuple, 0, len(m.remoteMonitors))
+
+	for _, r := range m.remoteMonitors {
+		monitors = append(monitors, r)
+	}
+
+	return monitors
+}
+
+func (m *Machine) getRemoteLinks() []remoteLinkTuple {
+	m.monitorsMu.Lock()
+	defer m.monitorsMu.Unlock()
+
+	links := make([]remoteLinkTuple, 0, len(m.remoteLinks))
+
+	for _, r := range m.remoteLinks {
+		links = append(links, r)
+	}
+
+	return links
+}
+
+func monitorRequest(r remoteMonitorTuple) qpmd.Request {
+	return qpmd.Request{
+		RequestType: monitorMessageType,
+		Data: map[string]interface{}{
+			fromVal: r.From,
+			toVal:   r.To,
+		},
+	}
+}
+
+func encodeRemoteMessage(message remoteMessageTuple) ([]byte, error) {
+	msgMap, err := encodeValue(message.Message.Type(), message.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	spanCtxBytes := &bytes.Buffer{}
+	if message.SpanContext != nil {
+		_ = opentracing.GlobalTracer().Inject(message.SpanContext, opentracing.Binary, spanCtxBytes)
+	}
+
+	data := map[string]interface{}{
+		toVal:      message.To.Id,
+		typeVal:    message.Message.Type(),
+		messageVal: msgMap,
+		spanCtx:    spanCtxBytes.Bytes(),
+	}
+
+	if message.Sender != nil {
+		data[senderVal] = message.Sender
+	}
+
+	if message.ReplyTo != nil {
+		data[replyToVal] = message.ReplyTo
+	}
+
+	return msgpack.Marshal(data)
 }
 
 func (m *Machine) startMessageClient(mb *mailbox.Mailbox, gatewayQuitChan <-chan bool, okChan chan<- bool, errorChan chan<- error) {
 	logger.Debug("starting message client for remote machine",
 		"machine_id", m.MachineId)
 
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", m.Address, m.MessageGatewayPort))
+	conn, err := dial(m.Address, m.MessageGatewayPort)
+	if err != nil {
+		errorChan <- err
+		return
+	}
+
+	err = clientHandshake(conn, map[string]interface{}{
+		qpmd.MACHINE_ID: machineId,
+	})
+
 	if err != nil {
+		logger.Warn("couldn't say hello to message gateway of remote machine",
+			"machine_id", m.MachineId,
+			"error", err)
+		_ = conn.Close()
 		errorChan <- err
 		return
 	}
 
 	defer func() {
+		//if we reconnect, the messages stay in the mailbox
+		if getReconnectPolicy() != nil && !shuttingDown.Load() {
+			return
+		}
+
 		l := mb.Len()
 		if l != 0 {
 			//record dropped message metric
@@ -165,119 +400,190 @@ func (m *Machine) startMessageClient(mb *mailbox.Mailbox, gatewayQuitChan <-chan
 
 	okChan <- true
 
+	//the message that couldn't be sent before we reconnected goes first
+	if message := m.takeUnsent(); message != nil {
+		if !m.sendMessage(conn, *message) {
+			return
+		}
+	}
+
 	messageChan := mb.Out()
 
 	for {
 		select {
 		case mi := <-messageChan:
-			message := mi.(remoteMessageTuple)
+			if !m.sendMessage(conn, mi.(remoteMessageTuple)) {
+				return
+			}
+		case <-gatewayQuitChan:
+			logger.Info("closing connection to remote message gateway",
+				"machine_id", m.MachineId)
+			_ = conn.Close()
+			return
+		}
+	}
+}
 
-			if d, ok := message.Message.(DownMessage); ok {
-				logger.Trace("cleaning up old remote monitor abortable, local PID just went down",
-					"monitor_gpid", message.To.String(),
-					"monitored_pid", d.Who.Id)
+//sendMessage writes a message to the message gateway connection and
+//returns false if the connection is broken (the machine is stopped then)
+func (m *Machine) sendMessage(conn net.Conn, message remoteMessageTuple) bool {
+	if d, ok := message.Message.(DownMessage); ok {
+		logger.Trace("cleaning up old remote monitor abortable, local PID just went down",
+			"monitor_gpid", message.To.String(),
+			"monitored_pid", d.Who.Id)
+
+		remoteMonitorQuitAbortablesMu.Lock()
+		delete(remoteMonitorQuitAbortables, message.To.String()+"_"+d.Who.String())
+		remoteMonitorQuitAbortablesMu.Unlock()
+	}
 
-				remoteMonitorQuitAbortablesMu.Lock()
-				delete(remoteMonitorQuitAbortables, message.To.String()+"_"+d.Who.String())
-				remoteMonitorQuitAbortablesMu.Unlock()
-			}
+	b, err := encodeRemoteMessage(message)
 
-			msgMap, err := encodeValue(message.Message.Type(), message.Message)
-			if err != nil {
-				continue
-			}
+	if err != nil {
+		//a message that can't be encoded is dropped, the connection is fine though
+		logger.Warn("there was an error while encoding message for remote machine",
+			"receiver_gpid", message.To.String(),
+			"machine_id", m.MachineId,
+			"error", err)
+		metrics.RecordDropRemote(m.MachineId, 1)
+		return true
+	}
 
-			spanCtxBytes := &bytes.Buffer{}
-			if message.SpanContext != nil {
-				_ = opentracing.GlobalTracer().Inject(message.SpanContext, opentracing.Binary, spanCtxBytes)
-			}
+	err = writeFrame(conn, b)
 
-			b, err := msgpack.Marshal(map[string]interface{}{
-				toVal:      message.To.Id,
-				typeVal:    message.Message.Type(),
-				messageVal: msgMap,
-				spanCtx:    spanCtxBytes.Bytes(),
-			})
+	if errors.Is(err, ErrFrameTooLarge) {
+		logger.Warn("message for remote machine exceeds the maximum frame size",
+			"receiver_gpid", message.To.String(),
+			"machine_id", m.MachineId,
+			"size", len(b))
+		metrics.RecordDropRemote(m.MachineId, 1)
+		return true
+	}
 
-			if err != nil {
-				logger.Warn("there was an error while sending message to remote machine",
-					"receiver_gpid", message.To.String(),
-					"machine_id", m.MachineId,
-					"error", err)
-				m.stop()
-			}
+	if err != nil {
+		logger.Warn("there was an error while sending message to remote machine",
+			"receiver_gpid", message.To.String(),
+			"machine_id", m.MachineId,
+			"error", err)
 
-			_, err = conn.Write(b)
+		m.setUnsent(&message)
+		_ = conn.Close()
+		m.stop()
 
-			if err != nil {
-				logger.Warn("there was an error while sending message to remote machine",
-					"receiver_gpid", message.To.String(),
-					"machine_id", m.MachineId,
-					"error", err)
-				m.stop()
-			}
-		case <-gatewayQuitChan:
-			logger.Info("closing connection to remote message gateway",
-				"machine_id", m.MachineId)
-			_ = conn.Close()
-			return
-		}
+		return false
 	}
+
+	return true
 }
 
-func (m *Machine) startGpClient(gpQuitChan <-chan bool, quitChan <-chan *Pid, monitorChan <-chan remoteMonitorTuple, demonitorChan <-chan remoteMonitorTuple, newConnectionChan <-chan *Machine, okChan chan<- bool, errorChan chan<- error) {
+//requestFailed closes a broken general purpose connection and stops the
+//machine. The request that failed (if any) is sent first after a reconnect.
+func (m *Machine) requestFailed(conn net.Conn, req *qpmd.Request) {
+	if req != nil {
+		m.setUnsentRequest(req)
+	}
+
+	_ = conn.Close()
+	m.stop()
+}
+
+func (m *Machine) startGpClient(gpQuitChan <-chan bool, quitChan <-chan *Pid, monitorChan <-chan remoteMonitorTuple, demonitorChan <-chan remoteMonitorTuple, linkChan <-chan remoteLinkTuple, unlinkChan <-chan remoteLinkTuple, exitChan <-chan remoteExitTuple, globalNameChan <-chan remoteGlobalNameTuple, groupChan <-chan remoteGroupTuple, processInfoChan <-chan remoteProcessInfoTuple, newConnectionChan <-chan *Machine, okChan chan<- bool, errorChan chan<- error) {
 	logger.Debug("starting general purpose client for remote machine",
 		"machine_id", m.MachineId)
 
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", m.Address, m.GeneralPurposePort))
+	conn, err := dial(m.Address, m.GeneralPurposePort)
 	if err != nil {
 		errorChan <- err
 		return
 	}
 
-	err = sendRequest(conn, qpmd.Request{
-		RequestType: qpmd.REQUEST_HELLO,
-		Data: map[string]interface{}{
-			qpmd.MACHINE_ID:           machineId,
-			qpmd.MESSAGE_GATEWAY_PORT: messageGatewayPort,
-			qpmd.GP_GATEWAY_PORT:      gpGatewayPort,
-		},
+	err = clientHandshake(conn, map[string]interface{}{
+		qpmd.MACHINE_ID:           machineId,
+		qpmd.MESSAGE_GATEWAY_PORT: messageGatewayPort,
+		qpmd.GP_GATEWAY_PORT:      gpGatewayPort,
 	})
 
 	if err != nil {
+		logger.Warn("couldn't say hello to general purpose gateway of remote machine",
+			"machine_id", m.MachineId,
+			"error", err)
+		_ = conn.Close()
 		errorChan <- err
 		return
 	}
 
-	res, err := readResponse(conn)
+	okChan <- true
 
-	if err != nil {
-		errorChan <- err
-		return
+	//after a reconnect, the remote machine doesn't know about the monitors of our actors anymore
+	for _, r := range m.getRemoteMonitors() {
+		err := sendRequest(conn, monitorRequest(r))
+
+		if err != nil {
+			logger.Warn("there was an error while re-establishing monitor on remote machine",
+				"monitor_pid", r.From.Id,
+				"monitored_gpid", r.To.String(),
+				"machine_id", m.MachineId,
+				"error", err)
+			_ = conn.Close()
+			m.stop()
+			return
+		}
 	}
 
-	if res.ResponseType != qpmd.RESPONSE_OK {
-		errorChan <- errors.New("remote machine returned non okay result")
-		return
+	//the same goes for the links of our actors
+	for _, r := range m.getRemoteLinks() {
+		err := sendRequest(conn, qpmd.Request{
+			RequestType: linkMessageType,
+			Data: map[string]interface{}{
+				fromVal: r.From,
+				toVal:   r.To,
+			},
+		})
+
+		if err != nil {
+			logger.Warn("there was an error while re-establishing link on remote machine",
+				"link_pid", r.From.Id,
+				"linked_gpid", r.To.String(),
+				"machine_id", m.MachineId,
+				"error", err)
+			_ = conn.Close()
+			m.stop()
+			return
+		}
 	}
 
-	okChan <- true
+	//the request that couldn't be sent before we reconnected goes next
+	if req := m.takeUnsentRequest(); req != nil {
+		err := sendRequest(conn, *req)
+
+		if err != nil {
+			logger.Warn("there was an error while resending request to remote machine",
+				"request_type", req.RequestType,
+				"machine_id", m.MachineId,
+				"error", err)
+			m.requestFailed(conn, req)
+			return
+		}
+	}
 
 	for {
 		select {
 		case p := <-quitChan:
-			err := sendRequest(conn, qpmd.Request{
+			req := qpmd.Request{
 				RequestType: quitMessageType,
 				Data: map[string]interface{}{
 					pidVal: p.Id,
 				},
-			})
+			}
+
+			err := sendRequest(conn, req)
 			if err != nil {
 				logger.Warn("there was an error while sending kill command to remote machine",
 					"target_gpid", p.String(),
 					"machine_id", m.MachineId,
 					"error", err)
-				m.stop()
+				m.requestFailed(conn, &req)
+				return
 			}
 
 		case r := <-monitorChan:
@@ -285,35 +591,36 @@ func (m *Machine) startGpClient(gpQuitChan <-chan bool, quitChan <-chan *Pid, mo
 			//to the actual monitor. I.e. if the connection to the remote machine goes down, we also have to send out
 			//down messages to the monitors
 
-			err := sendRequest(conn, qpmd.Request{
-				RequestType: monitorMessageType,
-				Data: map[string]interface{}{
-					fromVal: r.From,
-					toVal:   r.To,
-				},
-			})
+			//this is the above mentioned link; if the remote connection goes down, a DownMessage is sent
+			//to the monitoring PID (but obviously from the local machine because the remote one already
+			//disconnected)
+			m.setupRemoteMonitor(r)
+
+			err := sendRequest(conn, monitorRequest(r))
 			if err != nil {
 				logger.Warn("there was an error while sending monitor request to remote machine",
 					"monitor_pid", r.From.Id,
 					"monitored_gpid", r.To.String(),
 					"machine_id", m.MachineId,
 					"error", err)
-				m.stop()
+				//the monitor is re-established after a reconnect anyway
+				m.requestFailed(conn, nil)
+				return
 			}
 
-			//this is the above mentioned link; if the remote connection goes down, a DownMessage is sent
-			//to the monitoring PID (but obviously from the local machine because the remote one already
-			//disconnected)
-			m.setupRemoteMonitor(r)
-
 		case r := <-demonitorChan:
-			err := sendRequest(conn, qpmd.Request{
+			//remove "link" to the connection
+			m.removeRemoteMonitor(r)
+
+			req := qpmd.Request{
 				RequestType: demonitorMessageType,
 				Data: map[string]interface{}{
 					fromVal: r.From,
 					toVal:   r.To,
 				},
-			})
+			}
+
+			err := sendRequest(conn, req)
 
 			if err != nil {
 				logger.Warn("there was an error while sending demonitor request to remote machine",
@@ -321,25 +628,175 @@ func (m *Machine) startGpClient(gpQuitChan <-chan bool, quitChan <-chan *Pid, mo
 					"monitored_pid", r.To.String(),
 					"machine_id", m.MachineId,
 					"error", err)
-				m.stop()
+				m.requestFailed(conn, &req)
+				return
 			}
 
-			//remove "link" to the connection
-			m.removeRemoteMonitor(r)
+		case r := <-linkChan:
+			//the link to the connection is set up by the linking pid itself (see Pid.addLink)
+
+			req := qpmd.Request{
+				RequestType: linkMessageType,
+				Data: map[string]interface{}{
+					fromVal: r.From,
+					toVal:   r.To,
+				},
+			}
+
+			err := sendRequest(conn, req)
+
+			if err != nil {
+				logger.Warn("there was an error while sending link request to remote machine",
+					"link_pid", r.From.Id,
+					"linked_gpid", r.To.String(),
+					"machine_id", m.MachineId,
+					"error", err)
+				m.requestFailed(conn, &req)
+				return
+			}
+
+		case r := <-unlinkChan:
+			req := qpmd.Request{
+				RequestType: unlinkMessageType,
+				Data: map[string]interface{}{
+					fromVal: r.From,
+					toVal:   r.To,
+				},
+			}
+
+			err := sendRequest(conn, req)
+
+			if err != nil {
+				logger.Warn("there was an error while sending unlink request to remote machine",
+					"link_pid", r.From.Id,
+					"linked_gpid", r.To.String(),
+					"machine_id", m.MachineId,
+					"error", err)
+				m.requestFailed(conn, &req)
+				return
+			}
+
+		case r := <-exitChan:
+			req := qpmd.Request{
+				RequestType: exitMessageType,
+				Data: map[string]interface{}{
+					fromVal:   r.From,
+					toVal:     r.To,
+					reasonVal: r.Reason,
+				},
+			}
+
+			err := sendRequest(conn, req)
+
+			if err != nil {
+				logger.Warn("there was an error while sending exit signal to remote machine",
+					"pid", r.From.Id,
+					"linked_gpid", r.To.String(),
+					"machine_id", m.MachineId,
+					"error", err)
+				m.requestFailed(conn, &req)
+				return
+			}
+
+		case r := <-globalNameChan:
+			var requestType qpmd.RequestType = globalRegisterMessageType
+			if r.Unregister {
+				requestType = globalUnregisterMessageType
+			}
+
+			req := qpmd.Request{
+				RequestType: requestType,
+				Data: map[string]interface{}{
+					nameVal: r.Name,
+					pidVal:  r.Pid,
+				},
+			}
+
+			err := sendRequest(conn, req)
+
+			if err != nil {
+				logger.Warn("there was an error while sending global name to remote machine",
+					"name", r.Name,
+					"gpid", r.Pid.String(),
+					"machine_id", m.MachineId,
+					"error", err)
+				m.requestFailed(conn, &req)
+				return
+			}
+
+		case r := <-groupChan:
+			var requestType qpmd.RequestType = groupJoinMessageType
+			if r.Leave {
+				requestType = groupLeaveMessageType
+			}
+
+			req := qpmd.Request{
+				RequestType: requestType,
+				Data: map[string]interface{}{
+					groupVal: r.Group,
+					pidVal:   r.Pid,
+				},
+			}
+
+			err := sendRequest(conn, req)
+
+			if err != nil {
+				logger.Warn("there was an error while sending process group membership to remote machine",
+					"group", r.Group,
+					"gpid", r.Pid.String(),
+					"machine_id", m.MachineId,
+					"error", err)
+				m.requestFailed(conn, &req)
+				return
+			}
+
+		case r := <-processInfoChan:
+			req := qpmd.Request{
+				RequestType: processInfoMessageType,
+				Data: map[string]interface{}{
+					requestIdVal: r.RequestId,
+					pidVal:       r.Pid.Id,
+					machineVal:   machineId,
+				},
+			}
+
+			if r.Info != nil {
+				req = qpmd.Request{
+					RequestType: processInfoResultMessageType,
+					Data: map[string]interface{}{
+						requestIdVal: r.RequestId,
+						infoVal:      r.Info,
+					},
+				}
+			}
+
+			err := sendRequest(conn, req)
+
+			if err != nil {
+				logger.Warn("there was an error while sending process info to remote machine",
+					"target_gpid", r.Pid.String(),
+					"machine_id", m.MachineId,
+					"error", err)
+				m.requestFailed(conn, &req)
+				return
+			}
 
 		case machine := <-newConnectionChan:
-			err := sendRequest(conn, qpmd.Request{
+			req := qpmd.Request{
 				RequestType: newConnectionMessageType,
 				Data: map[string]interface{}{
 					machineVal: machine,
 				},
-			})
+			}
+
+			err := sendRequest(conn, req)
 			if err != nil {
 				logger.Warn("there was an error while sending new connection information to remote machine",
 					"new_machine_id", machine.MachineId,
 					"machine_id", m.MachineId,
 					"error", err)
-				m.stop()
+				m.requestFailed(conn, &req)
+				return
 			}
 		case <-gpQuitChan:
 			logger.Info("closing connection to remote general purpose gateway",
@@ -351,7 +808,7 @@ func (m *Machine) startGpClient(gpQuitChan <-chan bool, quitChan <-chan *Pid, mo
 }
 
 func (m *Machine) connect() error {
-	//quitChan, monitorChan, demonitorChan and newConnectionChan each have buffers of 100
+	//quitChan, monitorChan, demonitorChan, linkChan, unlinkChan, exitChan, globalNameChan, groupChan, processInfoChan and newConnectionChan each have buffers of 100
 	//this is a, sort of, "close protection" for when a remote machine disconnects
 
 	//there is a short time frame (i.e. a couple ns) where the *Machine is closing
@@ -368,60 +825,94 @@ func (m *Machine) connect() error {
 	mb := mailbox.New()
 	monitorChan := make(chan remoteMonitorTuple, 100)
 	demonitorChan := make(chan remoteMonitorTuple, 100)
+	linkChan := make(chan remoteLinkTuple, 100)
+	unlinkChan := make(chan remoteLinkTuple, 100)
+	exitChan := make(chan remoteExitTuple, 100)
+	globalNameChan := make(chan remoteGlobalNameTuple, 100)
+	groupChan := make(chan remoteGroupTuple, 100)
+	processInfoChan := make(chan remoteProcessInfoTuple, 100)
 	newConnectionChan := make(chan *Machine, 100)
 
 	m.quitChan = quitChan
 	m.messageChan = mb.In()
 	m.monitorChan = monitorChan
 	m.demonitorChan = demonitorChan
+	m.linkChan = linkChan
+	m.unlinkChan = unlinkChan
+	m.exitChan = exitChan
+	m.globalNameChan = globalNameChan
+	m.groupChan = groupChan
+	m.processInfoChan = processInfoChan
 	m.newConnectionChan = newConnectionChan
 
 	m.scheduled = make(map[string]chan bool)
 	m.monitorQuitChannels = make(map[string]chan bool)
 	m.monitorsMu = &sync.Mutex{}
+	m.monitors = make(map[string]*Pid)
+	m.remoteMonitors = make(map[string]remoteMonitorTuple)
+	m.remoteLinks = make(map[string]remoteLinkTuple)
+	m.reconnecting = atomic.NewBool(false)
 
-	//Buffer size of 2 to avoid leaks if both connections fail
-	gatewayQuitChan := make(chan bool, 2)
-	gpQuitChan := make(chan bool, 2)
+	//the channels (and the mailbox) outlive the connections so
+	//nothing is lost if we have to reconnect (see Machine.reconnect)
+	m.start = func() error {
+		//Buffer size of 2 to avoid leaks if both connections fail
+		gatewayQuitChan := make(chan bool, 2)
+		gpQuitChan := make(chan bool, 2)
 
-	m.gatewayQuitChan = gatewayQuitChan
-	m.gpQuitChan = gpQuitChan
+		m.gatewayQuitChan = gatewayQuitChan
+		m.gpQuitChan = gpQuitChan
 
-	errorChan := make(chan error)
-	okChan := make(chan bool)
+		errorChan := make(chan error)
+		okChan := make(chan bool)
 
-	logger.Info("connecting to remote machine",
-		"machine_id", m.MachineId)
+		logger.Info("connecting to remote machine",
+			"machine_id", m.MachineId)
 
-	go m.startMessageClient(mb, gatewayQuitChan, okChan, errorChan)
+		go m.startMessageClient(mb, gatewayQuitChan, okChan, errorChan)
 
-	select {
-	case err := <-errorChan:
-		logger.Warn("there was an error while connecting to remote machine",
-			"machine_id", m.MachineId,
-			"error", err)
-		return err
-	case <-okChan:
-		//everything went fine
+		select {
+		case err := <-errorChan:
+			logger.Warn("there was an error while connecting to remote machine",
+				"machine_id", m.MachineId,
+				"error", err)
+			return err
+		case <-okChan:
+			//everything went fine
+		}
+
+		go m.startGpClient(gpQuitChan, quitChan, monitorChan, demonitorChan, linkChan, unlinkChan, exitChan, globalNameChan, groupChan, processInfoChan, newConnectionChan, okChan, errorChan)
+
+		select {
+		case err := <-errorChan:
+			gatewayQuitChan <- true
+			logger.Warn("there was an error while connecting to remote machine",
+				"machine_id", m.MachineId,
+				"error", err)
+			return err
+		case <-okChan:
+			//everything went fine
+		}
+
+		return nil
 	}
 
-	go m.startGpClient(gpQuitChan, quitChan, monitorChan, demonitorChan, newConnectionChan, okChan, errorChan)
+	err := m.start()
 
-	select {
-	case err := <-errorChan:
-		gatewayQuitChan <- true
-		logger.Warn("there was an error while connecting to remote machine",
-			"machine_id", m.MachineId,
-			"error", err)
+	if err != nil {
 		return err
-	case <-okChan:
-		//everything went fine
 	}
 
 	logger.Info("successfully established connection to remote machine",
 		"machine_id", m.MachineId)
 
-	m.connected = true
+	m.connected.Store(true)
+
+	//let the new machine know about the global names and process groups on our machine
+	go func() {
+		syncGlobalNames(m)
+		syncGroups(m)
+	}()
 
 	return nil
 }
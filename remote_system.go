@@ -2,9 +2,7 @@ package quacktors
 
 import (
 	"errors"
-	"fmt"
 	"github.com/Azer0s/qpmd"
-	"net"
 )
 
 //TODO: log
@@ -20,7 +18,7 @@ type RemoteSystem struct {
 }
 
 func (r *RemoteSystem) sayHello() error {
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", r.Address, r.Port))
+	conn, err := dial(r.Address, r.Port)
 	if err != nil {
 		return err
 	}
@@ -48,11 +46,11 @@ func (r *RemoteSystem) sayHello() error {
 
 //Remote gets a remote PID by its handler name.
 func (r *RemoteSystem) Remote(handlerName string) (*Pid, error) {
-	if !r.Machine.connected {
+	if !r.Machine.isConnected() {
 		return nil, errors.New("remote machine is not connected")
 	}
 
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", r.Address, r.Port))
+	conn, err := dial(r.Address, r.Port)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,115 @@
+package quacktors
+
+import (
+	"go.uber.org/atomic"
+	"time"
+)
+
+var shuttingDown = atomic.NewBool(false)
+
+//Shutdown gracefully shuts down the local quacktors node.
+//First, the message and general purpose gateways stop
+//accepting new connections. Then, every actor is sent a
+//PoisonPill so it can work through the messages that are
+//already in its mailbox and run its deferred actions (see
+//Context.Defer). Actors that are spawned while the node is
+//shutting down are sent a PoisonPill as well. Actors that
+//haven't gone down once the timeout has passed are killed
+//forcefully. Killed actors get at most the child shutdown
+//timeout (see config.SetChildShutdownTimeout) to go down and
+//run their deferred actions before they are abandoned. Finally,
+//all System servers and their qpmd heartbeats are closed.
+//Shutdown returns the PIDs of the actors that had to be killed
+//forcefully.
+func Shutdown(timeout time.Duration) []*Pid {
+	callInitIfNotCalled()
+
+	if shuttingDown.Swap(true) {
+		logger.Warn("quacktors is already shutting down")
+		return make([]*Pid, 0)
+	}
+
+	logger.Info("shutting down",
+		"timeout", timeout.String())
+
+	if messageGatewayListener != nil {
+		_ = messageGatewayListener.Close()
+	}
+
+	if gpGatewayListener != nil {
+		_ = gpGatewayListener.Close()
+	}
+
+	timeoutChan := time.After(timeout)
+	poisoned := make(map[string]bool)
+
+drain:
+	for {
+		pids := getPids()
+
+		if len(pids) == 0 {
+			break
+		}
+
+		for _, pid := range pids {
+			if poisoned[pid.Id] {
+				continue
+			}
+
+			poisoned[pid.Id] = true
+
+			//a full mailbox with the BLOCK_POLICY would block us
+			go doSend(pid, PoisonPill{}, nil)
+		}
+
+		for _, pid := range pids {
+			select {
+			case <-pid.done:
+			case <-timeoutChan:
+				break drain
+			}
+		}
+	}
+
+	killed := getPids()
+
+	for _, pid := range killed {
+		logger.Warn("actor didn't go down in time, killing it forcefully",
+			"pid", pid.Id)
+
+		//an actor that is stuck in Run doesn't read its quit channel
+		go pid.die()
+	}
+
+	abandon := make(chan bool)
+	abandonTimer := time.AfterFunc(childShutdownTimeout, func() {
+		close(abandon)
+	})
+
+	for _, pid := range killed {
+		select {
+		case <-pid.done:
+		case <-abandon:
+			logger.Warn("actor didn't go down after it was killed, abandoning it",
+				"pid", pid.Id,
+				"timeout", childShutdownTimeout.String())
+		}
+	}
+
+	abandonTimer.Stop()
+
+	systemsMu.Lock()
+	for _, system := range systems {
+		logger.Info("closing system",
+			"system_name", system.name)
+
+		system.Close()
+	}
+	systems = make([]*System, 0)
+	systemsMu.Unlock()
+
+	logger.Info("shut down",
+		"killed_actors", len(killed))
+
+	return killed
+}
@@ -0,0 +1,81 @@
+package quacktors
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+//Shutdown can't be undone (it closes the gateways and systems of
+//the whole node), so it runs in a process of its own
+func TestShutdown(t *testing.T) {
+	if os.Getenv("QUACKTORS_SHUTDOWN_TEST") == "1" {
+		testShutdown(t)
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestShutdown$", "-test.count=1")
+	cmd.Env = append(os.Environ(), "QUACKTORS_SHUTDOWN_TEST=1")
+
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(out))
+}
+
+func testShutdown(t *testing.T) {
+	rootCtx := RootContext()
+
+	s, err := NewSystem("shutdown")
+	if err != nil {
+		panic(err)
+	}
+
+	processed := make(chan int, 1)
+	deferred := make(chan bool, 1)
+
+	count := 0
+	p := SpawnWithInit(func(ctx *Context) {
+		ctx.Defer(func() {
+			processed <- count
+			deferred <- true
+		})
+	}, func(ctx *Context, message Message) {
+		<-time.After(1 * time.Millisecond)
+		count++
+	})
+
+	for i := 0; i < 10; i++ {
+		rootCtx.Send(p, EmptyMessage{})
+	}
+
+	stubbornDeferred := make(chan bool, 1)
+
+	stubborn := SpawnWithInit(func(ctx *Context) {
+		ctx.PassthroughPoisonPill(true)
+		ctx.Defer(func() {
+			stubbornDeferred <- true
+		})
+	}, func(ctx *Context, message Message) {
+	})
+
+	killed := Shutdown(500 * time.Millisecond)
+
+	//the mailbox was drained before the actor went down
+	assert.Equal(t, 10, <-processed)
+	assert.True(t, <-deferred)
+
+	assert.Len(t, killed, 1)
+	assert.True(t, killed[0].Is(stubborn))
+
+	//Shutdown waited for the killed actor to go down
+	select {
+	case <-stubbornDeferred:
+	default:
+		t.Error("killed actor didn't run its deferred actions before Shutdown returned")
+	}
+
+	assert.True(t, s.IsClosed())
+
+	Run()
+}
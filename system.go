@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/Azer0s/qpmd"
+	"go.uber.org/atomic"
 	"net"
 	"sync"
 )
@@ -22,9 +23,10 @@ type System struct {
 	name              string
 	handlers          map[string]*Pid
 	handlersMu        *sync.RWMutex
-	quitChan          chan bool
+	listener          net.Listener
 	heartbeatQuitChan chan bool
-	closed            bool
+	//closed is a zero value so the empty System that is returned on errors works too
+	closed atomic.Bool
 }
 
 //HandleRemote associates a PID with a handler name.
@@ -38,15 +40,25 @@ func (s *System) HandleRemote(name string, process *Pid) {
 //IsClosed returns true if the connection to the
 //local qpmd or the system server were closed.
 func (s *System) IsClosed() bool {
-	return s.closed
+	return s.closed.Load()
 }
 
 //Close closes the connection to the local qpmd
 //and quits the system server.
 func (s *System) Close() {
-	s.closed = true
-	s.quitChan <- true
+	if s.closed.Swap(true) {
+		return
+	}
+
 	s.heartbeatQuitChan <- true
+	s.closeServer()
+}
+
+func (s *System) closeServer() {
+	//closing the listener makes the system server stop accepting clients
+	if s.listener != nil {
+		_ = s.listener.Close()
+	}
 }
 
 func (s *System) startServer() (uint16, error) {
@@ -54,13 +66,15 @@ func (s *System) startServer() (uint16, error) {
 		"system_name", s.name)
 
 	return startServer(func(portChan chan int, errorChan chan error) {
-		listener, err := net.Listen("tcp", ":0")
+		listener, err := listen()
 
 		if err != nil {
 			errorChan <- errors.New("couldn't start system server on random port")
 			return
 		}
 
+		s.listener = listener
+
 		port := listener.Addr().(*net.TCPAddr).Port
 		portChan <- port
 
@@ -68,27 +82,26 @@ func (s *System) startServer() (uint16, error) {
 			"system_name", s.name)
 
 		for {
-			select {
-			case <-s.quitChan:
-				logger.Info("quitting system server",
-					"system_name", s.name)
-				return
-			default:
-				conn, err := listener.Accept()
-				if err != nil {
-					logger.Warn("there was an error while accepting an incoming client for system",
-						"system_name", s.name,
-						"error", err)
-
-					continue
+			conn, err := listener.Accept()
+			if err != nil {
+				if s.closed.Load() {
+					logger.Info("quitting system server",
+						"system_name", s.name)
+					return
 				}
 
-				logger.Info("handling incoming client for system",
+				logger.Warn("there was an error while accepting an incoming client for system",
 					"system_name", s.name,
-					"client", conn.RemoteAddr().String())
+					"error", err)
 
-				go s.handleClient(conn)
+				continue
 			}
+
+			logger.Info("handling incoming client for system",
+				"system_name", s.name,
+				"client", conn.RemoteAddr().String())
+
+			go s.handleClient(conn)
 		}
 	})
 }
@@ -0,0 +1,177 @@
+package quacktors
+
+import (
+	"sync"
+	"time"
+)
+
+type actorTimer struct {
+	quitChan chan bool
+	once     *sync.Once
+	//canceled is set (guarded by timersMu) once the timer was canceled or replaced (or its owner went down)
+	canceled bool
+	//claimed is set (guarded by timersMu) once the message of a one-shot timer was accepted
+	claimed bool
+}
+
+//A timerTag marks a message that was sent by a timer. The receiving
+//actor drops the message if the timer was canceled or replaced (or
+//its owner went down) after the message was sent.
+type timerTag struct {
+	owner    *Pid
+	key      string
+	timer    *actorTimer
+	periodic bool
+}
+
+//claim returns false if the message of the timer is stale.
+func (tt *timerTag) claim() bool {
+	tt.owner.timersMu.Lock()
+	defer tt.owner.timersMu.Unlock()
+
+	if tt.timer.claimed {
+		return true
+	}
+
+	if tt.timer.canceled {
+		return false
+	}
+
+	if !tt.periodic {
+		tt.timer.claimed = true
+
+		if tt.owner.firedTimers[tt.key] == tt.timer {
+			delete(tt.owner.firedTimers, tt.key)
+		}
+	}
+
+	return true
+}
+
+func (t *actorTimer) stop() {
+	t.once.Do(func() {
+		close(t.quitChan)
+	})
+}
+
+//cancel stops the timer and marks its messages as stale (timersMu has to be held)
+func (t *actorTimer) cancel() {
+	t.canceled = true
+	t.stop()
+}
+
+func (pid *Pid) startTimer(key string, to *Pid, message Message, duration time.Duration, periodic bool) {
+	pid.timersMu.Lock()
+	defer pid.timersMu.Unlock()
+
+	if pid.timers == nil {
+		//the actor is already down
+		return
+	}
+
+	//starting a timer with the same key replaces the old one
+	if old, ok := pid.timers[key]; ok {
+		old.cancel()
+	}
+
+	if old, ok := pid.firedTimers[key]; ok {
+		old.cancel()
+		delete(pid.firedTimers, key)
+	}
+
+	t := &actorTimer{
+		quitChan: make(chan bool),
+		once:     &sync.Once{},
+	}
+	pid.timers[key] = t
+
+	m := localMessage{
+		message: message,
+		sender:  pid,
+		timer: &timerTag{
+			owner:    pid,
+			key:      key,
+			timer:    t,
+			periodic: periodic,
+		},
+	}
+
+	go func() {
+		if !periodic {
+			timer := time.NewTimer(duration)
+			defer timer.Stop()
+
+			select {
+			case <-t.quitChan:
+			case <-timer.C:
+				err := deliver(to, m)
+				pid.timerFired(key, t, to, err == nil)
+			}
+
+			return
+		}
+
+		ticker := time.NewTicker(duration)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-t.quitChan:
+				return
+			case <-ticker.C:
+				_ = deliver(to, m)
+			}
+		}
+	}()
+}
+
+//timerFired removes a one-shot timer once its message was delivered. If
+//the actor sent the message to itself, the timer is kept around (but not
+//reported as running) until the message is received, so canceling or
+//replacing the timer in the meantime still drops the message.
+func (pid *Pid) timerFired(key string, t *actorTimer, to *Pid, delivered bool) {
+	pid.timersMu.Lock()
+	defer pid.timersMu.Unlock()
+
+	if pid.timers[key] != t {
+		//the timer was canceled or replaced in the meantime (or the actor went down)
+		return
+	}
+
+	delete(pid.timers, key)
+
+	if delivered && to.Is(pid) && !t.claimed {
+		pid.firedTimers[key] = t
+	}
+}
+
+func (pid *Pid) cancelTimer(key string) {
+	pid.timersMu.Lock()
+	defer pid.timersMu.Unlock()
+
+	if t, ok := pid.timers[key]; ok {
+		t.cancel()
+		delete(pid.timers, key)
+	}
+
+	if t, ok := pid.firedTimers[key]; ok {
+		t.cancel()
+		delete(pid.firedTimers, key)
+	}
+}
+
+func (pid *Pid) stopTimers() {
+	pid.timersMu.Lock()
+	defer pid.timersMu.Unlock()
+
+	for _, t := range pid.timers {
+		t.cancel()
+	}
+
+	for _, t := range pid.firedTimers {
+		t.cancel()
+	}
+
+	pid.timers = nil
+	pid.firedTimers = nil
+}
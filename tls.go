@@ -0,0 +1,96 @@
+package quacktors
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/Azer0s/quacktors/config"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"sync"
+)
+
+//ErrInvalidCA is returned if the CA file of the TLS config
+//doesn't contain any PEM encoded certificates.
+var ErrInvalidCA = errors.New("CA file doesn't contain any certificates")
+
+//Both are nil if TLS is disabled (guarded by tlsMu)
+var serverTLSConfig *tls.Config
+var clientTLSConfig *tls.Config
+var tlsMu = &sync.RWMutex{}
+
+func setTLSConfig(server *tls.Config, client *tls.Config) {
+	tlsMu.Lock()
+	defer tlsMu.Unlock()
+
+	serverTLSConfig = server
+	clientTLSConfig = client
+}
+
+func getTLSConfig() (*tls.Config, *tls.Config) {
+	tlsMu.RLock()
+	defer tlsMu.RUnlock()
+
+	return serverTLSConfig, clientTLSConfig
+}
+
+func loadTLSConfig(c *config.TLSConfig) (*tls.Config, *tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pool *x509.CertPool
+
+	if c.CAFile != "" {
+		b, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pool = x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, nil, ErrInvalidCA
+		}
+	}
+
+	server := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if c.VerifyClient {
+		server.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	client := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	return server, client, nil
+}
+
+//listen starts listening on a random port (with TLS if it's enabled)
+func listen() (net.Listener, error) {
+	if server, _ := getTLSConfig(); server != nil {
+		return tls.Listen("tcp", ":0", server)
+	}
+
+	return net.Listen("tcp", ":0")
+}
+
+//dial connects to a gateway or system server of a remote machine (with TLS if it's enabled)
+func dial(address string, port uint16) (net.Conn, error) {
+	addr := net.JoinHostPort(address, strconv.Itoa(int(port)))
+
+	if _, client := getTLSConfig(); client != nil {
+		return tls.Dial("tcp", addr, client)
+	}
+
+	return net.Dial("tcp", addr)
+}
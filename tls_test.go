@@ -0,0 +1,328 @@
+package quacktors
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/Azer0s/qpmd"
+	"github.com/Azer0s/quacktors/config"
+	"github.com/Azer0s/quacktors/mailbox"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePem(t *testing.T, path string, blockType string, b []byte) {
+	err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: b}), 0600)
+	assert.NoError(t, err)
+}
+
+//writeTestCertificates creates a self-signed CA and a certificate for
+//localhost (signed by the CA) and returns the TLS config for them
+func writeTestCertificates(t *testing.T, verifyClient bool) *config.TLSConfig {
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "quacktors test CA"},
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().Add(1 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	caDer, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	assert.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	cert := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(1 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	certDer, err := x509.CreateCertificate(rand.Reader, cert, ca, &key.PublicKey, caKey)
+	assert.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	c := &config.TLSConfig{
+		CertFile:     filepath.Join(dir, "cert.pem"),
+		KeyFile:      filepath.Join(dir, "key.pem"),
+		CAFile:       filepath.Join(dir, "ca.pem"),
+		VerifyClient: verifyClient,
+	}
+
+	writePem(t, c.CAFile, "CERTIFICATE", caDer)
+	writePem(t, c.CertFile, "CERTIFICATE", certDer)
+	writePem(t, c.KeyFile, "EC PRIVATE KEY", keyDer)
+
+	return c
+}
+
+func withTLS(t *testing.T, c *config.TLSConfig) func() {
+	server, client, err := loadTLSConfig(c)
+	assert.NoError(t, err)
+
+	setTLSConfig(server, client)
+
+	return func() {
+		setTLSConfig(nil, nil)
+	}
+}
+
+func TestTLS(t *testing.T) {
+	rootCtx := RootContext()
+
+	defer withTLS(t, writeTestCertificates(t, true))()
+
+	//a separate message gateway with TLS
+	listener, err := listen()
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		handleMessageClient(conn)
+	}()
+
+	received := make(chan Message, 1)
+
+	p := Spawn(func(ctx *Context, message Message) {
+		received <- message
+	})
+
+	m := &Machine{
+		MachineId:          "tls",
+		Address:            "127.0.0.1",
+		MessageGatewayPort: uint16(listener.Addr().(*net.TCPAddr).Port),
+	}
+
+	mb := mailbox.New()
+	gatewayQuitChan := make(chan bool, 1)
+	okChan := make(chan bool, 1)
+	errorChan := make(chan error, 1)
+
+	go m.startMessageClient(mb, gatewayQuitChan, okChan, errorChan)
+
+	select {
+	case <-okChan:
+	case err := <-errorChan:
+		t.Fatal(err)
+	}
+
+	mb.In() <- remoteMessageTuple{To: p, Message: GenericMessage{Value: "encrypted"}}
+
+	select {
+	case m := <-received:
+		assert.Equal(t, GenericMessage{Value: "encrypted"}, m)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message wasn't received")
+	}
+
+	gatewayQuitChan <- true
+	mb.Close()
+
+	rootCtx.Kill(p)
+
+	Run()
+}
+
+func TestTLSGeneralPurposeGateway(t *testing.T) {
+	rootCtx := RootContext()
+
+	defer withTLS(t, writeTestCertificates(t, true))()
+
+	//a separate general purpose gateway with TLS
+	listener, err := listen()
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go handleGpClient(conn)
+		}
+	}()
+
+	//the fake remote machine listens with TLS as well
+	f := newFlakyMachine(t)
+	defer f.close()
+
+	m := f.machine()
+	assert.NoError(t, m.connect())
+	registerMachine(m)
+
+	conn := f.connectBackTo(t, m, uint16(listener.Addr().(*net.TCPAddr).Port))
+
+	remote := &Pid{MachineId: "flaky", Id: "remote"}
+	exits := make(chan ExitMessage, 1)
+
+	trapping := SpawnWithInit(func(ctx *Context) {
+		ctx.TrapExits(true)
+		ctx.Link(remote)
+	}, func(ctx *Context, message Message) {
+		if e, ok := message.(ExitMessage); ok {
+			exits <- e
+		}
+	})
+
+	//our requests reach the remote machine...
+	req := f.awaitRequest(t, linkMessageType)
+	assert.Equal(t, trapping.Id, req.Data[fromVal].(map[string]interface{})["Id"])
+
+	//...and the requests of the remote machine reach us
+	assert.NoError(t, sendRequest(conn, qpmd.Request{
+		RequestType: exitMessageType,
+		Data: map[string]interface{}{
+			fromVal:   remote,
+			toVal:     trapping,
+			reasonVal: ExitReason{Kind: KILLED_EXIT},
+		},
+	}))
+
+	select {
+	case e := <-exits:
+		assert.Equal(t, remote.String(), e.Who.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't receive ExitMessage")
+	}
+
+	rootCtx.Kill(trapping)
+
+	m.disconnect()
+
+	Run()
+}
+
+func TestTLSSystemServer(t *testing.T) {
+	rootCtx := RootContext()
+
+	defer withTLS(t, writeTestCertificates(t, true))()
+
+	s, err := NewSystem("tls" + uuidString())
+	assert.NoError(t, err)
+	defer s.Close()
+
+	p := Spawn(func(ctx *Context, message Message) {})
+	s.HandleRemote("tls", p)
+
+	f := newFlakyMachine(t)
+	defer f.close()
+
+	m := f.machine()
+	assert.NoError(t, m.connect())
+
+	r := &RemoteSystem{
+		MachineId: machineId,
+		Address:   "127.0.0.1",
+		Port:      uint16(s.listener.Addr().(*net.TCPAddr).Port),
+		Machine:   m,
+	}
+
+	assert.NoError(t, r.sayHello())
+
+	remote, err := r.Remote("tls")
+	assert.NoError(t, err)
+	assert.True(t, p.Is(remote))
+
+	//clients without TLS don't get an answer
+	conn, err := net.Dial("tcp", s.listener.Addr().String())
+	assert.NoError(t, err)
+
+	assert.NoError(t, sendRequest(conn, qpmd.Request{
+		RequestType: qpmd.REQUEST_HELLO,
+		Data:        make(map[string]interface{}),
+	}))
+
+	_, err = readResponse(conn)
+	assert.Error(t, err)
+	_ = conn.Close()
+
+	rootCtx.Kill(p)
+
+	m.disconnect()
+
+	Run()
+}
+
+func TestTLSVerification(t *testing.T) {
+	RootContext()
+
+	c := writeTestCertificates(t, true)
+	defer withTLS(t, c)()
+
+	listener, err := listen()
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	_, client := getTLSConfig()
+
+	handshakes := make(chan error, 2)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			handshakes <- conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+
+	addr := listener.Addr().String()
+
+	//the server isn't trusted without the CA
+	_, err = tls.Dial("tcp", addr, &tls.Config{ServerName: "localhost"})
+	assert.Error(t, err)
+	assert.Error(t, <-handshakes)
+
+	//the server rejects clients without a certificate
+	conn, err := tls.Dial("tcp", addr, &tls.Config{
+		ServerName: "localhost",
+		RootCAs:    client.RootCAs,
+	})
+
+	if err == nil {
+		//with TLS 1.3 the client only notices once it reads
+		_, err = conn.Read(make([]byte, 1))
+		_ = conn.Close()
+	}
+
+	assert.Error(t, err)
+	assert.Error(t, <-handshakes)
+
+	c.CAFile = c.KeyFile
+	_, _, err = loadTLSConfig(c)
+	assert.Equal(t, ErrInvalidCA, err)
+}
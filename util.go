@@ -1,26 +1,63 @@
 package quacktors
 
 import (
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"github.com/Azer0s/qpmd"
 	"github.com/gofrs/uuid"
 	"github.com/opentracing/opentracing-go"
 	"github.com/vmihailenco/msgpack/v5"
+	"io"
 	"net"
 	"strings"
 	"time"
 )
 
-type quitAction struct{}
+type quitAction struct {
+	reason ExitReason
+}
 
 type remoteMonitorTuple struct {
 	From *Pid
 	To   *Pid
 }
 
+type remoteLinkTuple struct {
+	From *Pid
+	To   *Pid
+}
+
+type remoteExitTuple struct {
+	From   *Pid
+	To     *Pid
+	Reason ExitReason
+}
+
+type remoteGlobalNameTuple struct {
+	Name       string
+	Pid        *Pid
+	Unregister bool
+}
+
+type remoteGroupTuple struct {
+	Group string
+	Pid   *Pid
+	Leave bool
+}
+
+type remoteProcessInfoTuple struct {
+	RequestId string
+	Pid       *Pid
+	//Info is nil for requests and set for results
+	Info *ActorInfo
+}
+
 type remoteMessageTuple struct {
 	To      *Pid
 	Message Message
+	Sender  *Pid
+	ReplyTo *Pid
 	opentracing.SpanContext
 }
 
@@ -73,32 +110,83 @@ func parseMachine(rawData map[string]interface{}) (*Machine, error) {
 	return m, nil
 }
 
-func sendRequest(conn net.Conn, req qpmd.Request) error {
-	b, err := msgpack.Marshal(req)
+//Everything quacktors sends to another quacktors instance (messages
+//and requests) is framed, meaning it's prefixed with its length (4
+//bytes, big endian). A frame is only decoded once it has been read
+//completely, no matter how TCP splits or coalesces it.
+const frameHeaderSize = 4
 
+//ErrFrameTooLarge is returned if a frame is bigger than the
+//maximum frame size (see config.SetMaxFrameSize).
+var ErrFrameTooLarge = errors.New("frame exceeds the maximum frame size")
+
+func writeFrame(w io.Writer, b []byte) error {
+	if uint64(len(b)) > uint64(maxFrameSize) {
+		return ErrFrameTooLarge
+	}
+
+	//header and payload go out with one write so that nothing
+	//else can end up in between
+	frame := make([]byte, frameHeaderSize+len(b))
+	binary.BigEndian.PutUint32(frame, uint32(len(b)))
+	copy(frame[frameHeaderSize:], b)
+
+	_, err := w.Write(frame)
+
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	header := make([]byte, frameHeaderSize)
+
+	_, err := io.ReadFull(r, header)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = conn.Write(b)
+	size := binary.BigEndian.Uint32(header)
+
+	if size > maxFrameSize {
+		return nil, ErrFrameTooLarge
+	}
+
+	b := make([]byte, size)
+
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func writeFramed(w io.Writer, v interface{}) error {
+	b, err := msgpack.Marshal(v)
 
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return writeFrame(w, b)
 }
 
-func readResponse(conn net.Conn) (qpmd.Response, error) {
-	buf := make([]byte, 4096)
-	n, err := conn.Read(buf)
+func readFramed(r io.Reader, v interface{}) error {
+	b, err := readFrame(r)
 
 	if err != nil {
-		return qpmd.Response{}, err
+		return err
 	}
 
+	return msgpack.Unmarshal(b, v)
+}
+
+func sendRequest(conn net.Conn, req qpmd.Request) error {
+	return writeFramed(conn, req)
+}
+
+func readResponse(conn net.Conn) (qpmd.Response, error) {
 	res := qpmd.Response{}
-	err = msgpack.Unmarshal(buf[:n], &res)
+	err := readFramed(conn, &res)
 
 	if err != nil {
 		return qpmd.Response{}, err
@@ -108,14 +196,8 @@ func readResponse(conn net.Conn) (qpmd.Response, error) {
 }
 
 func readRequest(conn net.Conn) (qpmd.Request, error) {
-	buf := make([]byte, 4096)
-	n, err := conn.Read(buf)
-	if n == 0 || err != nil {
-		return qpmd.Request{}, err
-	}
-
 	req := qpmd.Request{}
-	err = msgpack.Unmarshal(buf[:n], &req)
+	err := readFramed(conn, &req)
 
 	if err != nil {
 		return qpmd.Request{}, err
@@ -127,19 +209,34 @@ func readRequest(conn net.Conn) (qpmd.Request, error) {
 func sendResponse(client net.Conn, response qpmd.Response) error {
 	response.Data[qpmd.TIMESTAMP] = time.Now().Unix()
 
-	b, err := msgpack.Marshal(response)
+	return writeFramed(client, response)
+}
+
+//qpmd doesn't frame its requests and responses, msgpack
+//values are self delimiting though so we just decode them
+//right off the connection
+
+func sendQpmdRequest(conn net.Conn, req qpmd.Request) error {
+	b, err := msgpack.Marshal(req)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = client.Write(b)
+	_, err = conn.Write(b)
+
+	return err
+}
+
+func readQpmdResponse(conn net.Conn) (qpmd.Response, error) {
+	res := qpmd.Response{}
+	err := msgpack.NewDecoder(conn).Decode(&res)
 
 	if err != nil {
-		return err
+		return qpmd.Response{}, err
 	}
 
-	return nil
+	return res, nil
 }
 
 func writeError(client net.Conn, err error) error {